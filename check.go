@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"github.com/levavakian/rowm/frame"
+)
+
+// CheckConfig parses a config file and prints every problem found with it, returning the exit code.
+// Key strings are resolved against the keymap of the current display if one is available.
+func CheckConfig(filename string) int {
+	fmt.Println("checking", filename)
+
+	conf, err := frame.LoadConfig(filename)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	X, err := xgbutil.NewConn()
+	if err != nil {
+		fmt.Println("could not connect to X, key strings will only be checked by name:", err)
+		X = nil
+	} else {
+		defer X.Conn().Close()
+		keybind.Initialize(X)
+	}
+
+	problems := conf.CheckKeyBindings(X)
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	if len(problems) > 0 {
+		fmt.Println(len(problems), "problem(s) found")
+		return 1
+	}
+
+	fmt.Println("config ok")
+	return 0
+}
@@ -0,0 +1,91 @@
+// rowmctl sends commands to a running rowm over its command socket.
+// It is meant for driving the window manager from scripts and editor plugins.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/levavakian/rowm/ipc"
+	"os"
+)
+
+const usage = `usage: rowmctl [-window ID] COMMAND [ARGS...]
+
+commands:
+  tree                                  print every container and its frames as JSON
+  split horizontal|vertical COMMAND     run COMMAND and split the window to make room for it
+  yank [frame|container]                select the window or its whole container to move
+  paste [horizontal|vertical]           move the yanked window or container into a split of the window
+  pop                                   move the window out of its split into its own container
+  minimize                              minimize or restore the window's container
+  anchor up|down|left|right             move the window's container to the next anchor in a direction
+  focus [next|prev]                     focus the window, or the next or previous window in its container
+  taskbar                               show or hide the taskbar
+  lock                                  lock the screen
+  run KEY|NAME                          run a builtin command by its key or name
+  subscribe [TYPE...]                   print events as they happen, one JSON object per line
+                                        (manage, unmanage, destroy, focus, minimize, restore,
+                                        screens, lock, unlock), all of them if no TYPE is given
+
+Commands act on the focused window unless -window is given.
+`
+
+func main() {
+	window := flag.Uint("window", 0, "id of the window to act on instead of the focused window")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	client, err := ipc.Dial()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not connect to rowm:", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	resp, err := client.Call(ipc.Request{
+		Command: flag.Arg(0),
+		Args:    flag.Args()[1:],
+		Window:  uint32(*window),
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if resp.Error != "" {
+		fmt.Fprintln(os.Stderr, resp.Error)
+		os.Exit(1)
+	}
+
+	if flag.Arg(0) == "subscribe" {
+		for {
+			ev, err := client.ReadEvent()
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			line, err := json.Marshal(ev)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			fmt.Println(string(line))
+		}
+	}
+
+	if len(resp.Data) > 0 {
+		var out bytes.Buffer
+		if err := json.Indent(&out, resp.Data, "", "  "); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(out.String())
+	}
+}
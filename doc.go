@@ -3,11 +3,14 @@ rowm is a hybrid tiling manager aiming to replicate the ergonomics of terminator
 For more information on the usage, please read the README instead.
 
 main.go - entrypoint, initializes connection to X and connects root callbacks
+check.go - the --check-config mode, which reports problems with a config file without starting the window manager
 root/ - callbacks for the root window are defined here
 frame/ - the majority of the logic is in this folder, defines the tree window structure and wrapping decorations
 sideloop/ - utilities for running code in line with the X event loop from xgbutil
+ipc/ - the messages spoken over the command socket
 resources/ - non compiled data resources
 cmd/rowmbright/ - a utility for changing backlight brightness without root privileges
+cmd/rowmctl/ - a command line client for the command socket
 ext/ - misc bits and bobs missing in either the standard library or xgbutil
 
 dev.sh - starts up a container for developing in (required for running compile.sh or test.sh)
@@ -3,6 +3,9 @@ package ext
 import (
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/icccm"
+	"github.com/BurntSushi/xgbutil/keybind"
 	"github.com/BurntSushi/xgbutil/xwindow"
 	"log"
 	"math"
@@ -22,6 +25,13 @@ func IMin(a, b int) int {
 	return b
 }
 
+func IAbs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
 func IClamp(n, min, max int) int {
 	return IMax(IMin(n, max), min)
 }
@@ -57,3 +67,73 @@ func Logerr(err error) {
 		log.Println(err)
 	}
 }
+
+// RebindKeys replaces every key binding on the windows with the ones connected by bind.
+// keybind.Detach can't be used for this since it leaves keybind's event handler connected
+// to the window, so reconnecting afterwards would run every binding twice.
+func RebindKeys(X *xgbutil.XUtil, bind func() error, wins ...xproto.Window) error {
+	rebound := make(map[xproto.Window]bool)
+	for _, win := range wins {
+		rebound[win] = true
+	}
+
+	X.KeybindsLck.Lock()
+	old := make(map[xgbutil.KeyKey]int)
+	for key, cbs := range X.Keybinds {
+		if rebound[key.Win] {
+			old[key] = len(cbs)
+		}
+	}
+	kept := make([]xgbutil.KeyString, 0, len(X.Keystrings))
+	for _, ks := range X.Keystrings {
+		if !rebound[ks.Win] {
+			kept = append(kept, ks)
+		}
+	}
+	numStrings := len(X.Keystrings)
+	X.KeybindsLck.Unlock()
+
+	err := bind()
+
+	X.KeybindsLck.Lock()
+	// New callbacks are appended, so the old ones are always at the front
+	for key, n := range old {
+		X.Keybinds[key] = X.Keybinds[key][n:]
+		X.Keygrabs[key] -= n
+		if len(X.Keybinds[key]) == 0 {
+			delete(X.Keybinds, key)
+			delete(X.Keygrabs, key)
+		}
+	}
+	X.Keystrings = append(kept, X.Keystrings[numStrings:]...)
+
+	// Only ungrab combinations that no event type is using anymore
+	ungrab := make([]xgbutil.KeyKey, 0)
+	for key := range old {
+		used := false
+		for other := range X.Keybinds {
+			if other.Win == key.Win && other.Mod == key.Mod && other.Code == key.Code {
+				used = true
+				break
+			}
+		}
+		if !used {
+			ungrab = append(ungrab, key)
+		}
+	}
+	X.KeybindsLck.Unlock()
+
+	for _, key := range ungrab {
+		keybind.Ungrab(X, key.Win, key.Mod, key.Code)
+	}
+	return err
+}
+
+// WindowTitle returns the title of a window, preferring the EWMH name over the ICCCM one.
+func WindowTitle(X *xgbutil.XUtil, w xproto.Window) string {
+	if title, err := ewmh.WmNameGet(X, w); err == nil {
+		return title
+	}
+	title, _ := icccm.WmNameGet(X, w)
+	return title
+}
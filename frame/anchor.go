@@ -12,9 +12,7 @@ const (
 )
 
 func AnchorShape(ctx *Context, screen Rect, anchor AnchorType) Rect {
-	if !ctx.Taskbar.Hidden && screen == ctx.Screens[0] {
-		screen.H = screen.H - ctx.Config.TaskbarHeight
-	}
+	screen = ctx.WorkArea(screen)
 
 	if anchor == TOP {
 		screen.H = screen.H / 2
@@ -58,3 +56,94 @@ func AnchorMatch(ctx *Context, screen Rect, shape Rect) AnchorType {
 	}
 	return NONE
 }
+
+// KeepAnchored makes a change to the work area of the screens, moving containers that were anchored
+// or maximized so that they stay that way in the new work area.
+func KeepAnchored(ctx *Context, change func()) {
+	type anchorTo struct {
+		anchor AnchorType
+		screen Rect
+	}
+	updates := make(map[*Container]anchorTo)
+	for c, _ := range ctx.Containers {
+		screen, _, _ := ctx.GetScreenForShape(c.Shape)
+		if opt := AnchorMatch(ctx, screen, c.Shape); opt != NONE {
+			updates[c] = anchorTo{opt, screen}
+		}
+	}
+
+	change()
+
+	for c, a := range updates {
+		c.MoveResizeShape(ctx, AnchorShape(ctx, a.screen, a.anchor))
+	}
+	// Windows maximized in only one direction don't match an anchor, fullscreen ones cover the taskbar anyway
+	for c := range ctx.Containers {
+		if _, ok := updates[c]; ok || c.WmState.IsNormal() || c.WmState.Fullscreen {
+			continue
+		}
+		screen, _, _ := ctx.GetScreenForShape(c.Shape)
+		c.MoveResizeShape(ctx, c.MaximizedShape(ctx, screen, c.Shape))
+	}
+	ctx.UpdateWorkArea()
+}
+
+// MoveToAnchor moves a container one step in a direction (TOP, BOTTOM, LEFT or RIGHT) through the
+// anchors of its screen, continuing on to the next screen over once it is already at the edge.
+func (c *Container) MoveToAnchor(ctx *Context, direction AnchorType) {
+	ctx.RecordLayout()
+	screen, _, _ := ctx.GetScreenForShape(c.Shape)
+	switch direction {
+	case TOP:
+		if c.Shape == AnchorShape(ctx, screen, FULL) {
+			s := AnchorShape(ctx, screen, TOP)
+			c.MoveResizeShape(ctx, s)
+		} else if c.Shape == AnchorShape(ctx, screen, TOP) {
+			raised := screen
+			raised.Y = raised.Y - raised.H
+			if nscreen, overlap, _ := ctx.GetScreenForShape(raised); overlap > 0 && nscreen != screen {
+				c.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, BOTTOM))
+			}
+		} else if c.Shape == AnchorShape(ctx, screen, BOTTOM) {
+			c.MoveResizeShape(ctx, c.RestingShape(ctx, screen))
+		} else {
+			c.MoveResizeShape(ctx, AnchorShape(ctx, screen, FULL))
+		}
+	case BOTTOM:
+		if c.Shape == AnchorShape(ctx, screen, FULL) || c.Shape == AnchorShape(ctx, screen, TOP) {
+			c.MoveResizeShape(ctx, c.RestingShape(ctx, screen))
+		} else if c.Shape == AnchorShape(ctx, screen, BOTTOM) {
+			lowered := screen
+			lowered.Y = lowered.Y + lowered.H
+			if nscreen, overlap, _ := ctx.GetScreenForShape(lowered); overlap > 0 && nscreen != screen {
+				c.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, TOP))
+			}
+		} else {
+			c.MoveResizeShape(ctx, AnchorShape(ctx, screen, BOTTOM))
+		}
+	case LEFT:
+		if c.Shape == AnchorShape(ctx, screen, RIGHT) {
+			c.MoveResizeShape(ctx, c.RestingShape(ctx, screen))
+		} else if c.Shape == AnchorShape(ctx, screen, LEFT) {
+			lefted := screen
+			lefted.X = lefted.X - lefted.W
+			if nscreen, overlap, _ := ctx.GetScreenForShape(lefted); overlap > 0 && nscreen != screen {
+				c.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, RIGHT))
+			}
+		} else {
+			c.MoveResizeShape(ctx, AnchorShape(ctx, screen, LEFT))
+		}
+	case RIGHT:
+		if c.Shape == AnchorShape(ctx, screen, LEFT) {
+			c.MoveResizeShape(ctx, c.RestingShape(ctx, screen))
+		} else if c.Shape == AnchorShape(ctx, screen, RIGHT) {
+			righted := screen
+			righted.X = righted.X + righted.W
+			if nscreen, overlap, _ := ctx.GetScreenForShape(righted); overlap > 0 && nscreen != screen {
+				c.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, LEFT))
+			}
+		} else {
+			c.MoveResizeShape(ctx, AnchorShape(ctx, screen, RIGHT))
+		}
+	}
+}
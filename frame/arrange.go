@@ -0,0 +1,133 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/xcursor"
+	"github.com/levavakian/rowm/ext"
+)
+
+// SplitAbove returns the closest frame above f that splits its children side by side, skipping tab groups.
+func (f *Frame) SplitAbove() *Frame {
+	for p := f.Parent; p != nil; p = p.Parent {
+		if !p.IsTabbed() {
+			return p
+		}
+	}
+	return nil
+}
+
+// SeparatorCursor is the cursor shown over the separator of a split, matching the way it can be dragged.
+func (f *Frame) SeparatorCursor(ctx *Context) xproto.Cursor {
+	if f.Separator.Type == VERTICAL {
+		return ctx.Cursors[xcursor.SBVDoubleArrow]
+	}
+	return ctx.Cursors[xcursor.SBHDoubleArrow]
+}
+
+// Rotate turns the closest split above f between HORIZONTAL and VERTICAL.
+func (f *Frame) Rotate(ctx *Context) {
+	p := f.SplitAbove()
+	if p == nil {
+		return
+	}
+	ctx.RecordLayout()
+	if p.Separator.Type == HORIZONTAL {
+		p.Separator.Type = VERTICAL
+	} else {
+		p.Separator.Type = HORIZONTAL
+	}
+	for _, d := range p.Separator.Decorations {
+		ext.Logerr(xproto.ChangeWindowAttributesChecked(ctx.X.Conn(), d.Window.Id,
+			xproto.CwCursor, []uint32{uint32(p.SeparatorCursor(ctx))}).Check())
+	}
+	p.FitWeights(ctx)
+	p.MoveResize(ctx)
+}
+
+// Flip reverses the order of the children of the split f is in, each child keeps its size.
+func (f *Frame) Flip(ctx *Context) {
+	p := f.Parent
+	if p == nil {
+		return
+	}
+	ctx.RecordLayout()
+	p.NormalizeWeights()
+	for i, j := 0, len(p.Children)-1; i < j; i, j = i+1, j-1 {
+		p.Children[i], p.Children[j] = p.Children[j], p.Children[i]
+		p.Separator.Weights[i], p.Separator.Weights[j] = p.Separator.Weights[j], p.Separator.Weights[i]
+	}
+	if p.IsTabbed() {
+		f.Container.UpdateTabs(ctx)
+	}
+	p.MoveResize(ctx)
+}
+
+// LeafWeight counts how many leaves share the space of a frame, tabs share all of it so only the largest one counts.
+func (f *Frame) LeafWeight() int {
+	weight := 0
+	for _, child := range f.Children {
+		if f.IsTabbed() {
+			weight = ext.IMax(weight, child.LeafWeight())
+		} else {
+			weight += child.LeafWeight()
+		}
+	}
+	return ext.IMax(weight, 1)
+}
+
+// equalWeights gives every leaf under a split the same share of its space.
+func (f *Frame) equalWeights(ctx *Context) {
+	f.Separator.Weights = make([]float64, len(f.Children))
+	for i, child := range f.Children {
+		f.Separator.Weights[i] = float64(child.LeafWeight())
+	}
+	f.NormalizeWeights()
+	f.FitWeights(ctx)
+}
+
+// Equalize sets the weights of every split in a container so all of its leaves get the same area.
+func (c *Container) Equalize(ctx *Context) {
+	if c.Root == nil {
+		return
+	}
+	ctx.RecordLayout()
+	c.equalize(ctx)
+}
+
+func (c *Container) equalize(ctx *Context) {
+	c.Root.Traverse(func(f *Frame) {
+		if !f.IsLeaf() && !f.IsTabbed() {
+			f.equalWeights(ctx)
+		}
+	})
+	c.ActiveRoot().MoveResize(ctx)
+}
+
+// Balance merges every run of nested splits of the same type in a container into a single split, and gives
+// all of its leaves the same area, so windows added one after the other don't each get half of what the last one had.
+func (c *Container) Balance(ctx *Context) {
+	if c.Root == nil {
+		return
+	}
+	ctx.RecordLayout()
+	var balance func(f *Frame)
+	balance = func(f *Frame) {
+		if f.IsLeaf() {
+			return
+		}
+		for i := 0; i < len(f.Children); {
+			child := f.Children[i]
+			if !child.IsLeaf() && child.Separator.Type == f.Separator.Type && c.Expanded != child {
+				f.Splice(ctx, i, child)
+				continue
+			}
+			i++
+		}
+		for _, child := range f.Children {
+			balance(child)
+		}
+	}
+	balance(c.Root)
+	c.UpdateTabs(ctx)
+	c.equalize(ctx)
+}
@@ -23,6 +23,9 @@ type Config struct {
 	TabBackward               StringWithHelp
 	ButtonDrag                string
 	ButtonClick               string
+	ButtonDragFrame           string // Drags a frame by its window, to drop it next to another frame or out onto the desktop
+	ButtonMoveContainer       string // Moves the container under the pointer by dragging anywhere on it
+	ButtonResizeContainer     string // Resizes the container under the pointer from its closest corner
 	SplitVertical             StringWithHelp
 	SplitHorizontal           StringWithHelp
 	RunCmd                    StringWithHelp
@@ -46,6 +49,22 @@ type Config struct {
 	VolumeMute                string
 	FocusNext                 StringWithHelp
 	FocusPrev                 StringWithHelp
+	FocusLeft                 StringWithHelp
+	FocusDown                 StringWithHelp
+	FocusUp                   StringWithHelp
+	FocusRight                StringWithHelp
+	SwapLeft                  StringWithHelp
+	SwapDown                  StringWithHelp
+	SwapUp                    StringWithHelp
+	SwapRight                 StringWithHelp
+	RotateSplit               StringWithHelp
+	FlipSplit                 StringWithHelp
+	EqualizeSplits            StringWithHelp
+	BalanceSplits             StringWithHelp
+	ResizeMode                StringWithHelp // Arrows resize the focused frame and shift with arrows its container, until Escape
+	UndoLayout                StringWithHelp
+	RedoLayout                StringWithHelp
+	UndoLimit                 int // How many layout changes can be undone
 	ElemSize                  int
 	CloseCursor               int
 	DefaultShapeRatio         Rectf
@@ -78,15 +97,31 @@ type Config struct {
 	TaskbarMinMaxColor        uint32
 	TaskbarSlideLeft          string
 	TaskbarSlideRight         string
+	TaskbarWorkspaceColor       uint32
+	TaskbarWorkspaceActiveColor uint32
+	TaskbarWorkspaceUrgentColor uint32
 	CutSelectFrame            string
 	CutSelectContainer        string
 	CopySelectHorizontal      StringWithHelp
 	CopySelectVertical        StringWithHelp
+	CopySelectTabbed          StringWithHelp
+	TabHeight                 int
+	TabActiveColor            uint32
+	TabInactiveColor          uint32
 	SuspendCommand            string
 	BatteryWarningLevels      []int
 	BatteryWarningDuration    time.Duration
 	LaunchHelp                string
 	GotoKeys                  map[string]string
+	ReloadConfig              StringWithHelp
+	Restart                   StringWithHelp
+	Layouts                   map[string]Layout
+	LaunchLayout              StringWithHelp
+	LayoutTimeout             time.Duration
+	WorkspaceKeys             []string // One key to switch to each workspace, which also sets how many workspaces there are
+	MoveToWorkspaceKeys       []string // Keys to move the focused container to each workspace
+	MarkScratchpad            StringWithHelp
+	ToggleScratchpad          StringWithHelp
 }
 
 func HomeDir() string {
@@ -118,6 +153,9 @@ func DefaultConfig() Config {
 		TabBackward:             StringWithHelp{Data: "Mod1-Shift-tab", Help:"Tab Backward"},
 		ButtonDrag:              "1",
 		ButtonClick:             "1",
+		ButtonDragFrame:         "Mod4-Shift-1",
+		ButtonMoveContainer:     "Mod4-1",
+		ButtonResizeContainer:   "Mod4-3",
 		SplitVertical:           StringWithHelp{Data: "Mod4-r", Help:"Split Vertically"},
 		SplitHorizontal:         StringWithHelp{Data: "Mod4-e", Help:"Split Horizontally"},
 		RunCmd:                  StringWithHelp{Data: "Mod4-f", Help:"Run Command"},
@@ -140,6 +178,22 @@ func DefaultConfig() Config {
 		BrightnessDown:          "XF86MonBrightnessDown",
 		FocusNext:               StringWithHelp{Data: "Mod4-Tab", Help:"Focus Next"},
 		FocusPrev:               StringWithHelp{Data: "Mod4-asciitilde", Help:"Focus Previous"},
+		FocusLeft:               StringWithHelp{Data: "Mod4-Control-h", Help: "Focus Left"},
+		FocusDown:               StringWithHelp{Data: "Mod4-Control-j", Help: "Focus Down"},
+		FocusUp:                 StringWithHelp{Data: "Mod4-Control-k", Help: "Focus Up"},
+		FocusRight:              StringWithHelp{Data: "Mod4-Control-l", Help: "Focus Right"},
+		SwapLeft:                StringWithHelp{Data: "Mod4-Control-Shift-h", Help: "Swap Left"},
+		SwapDown:                StringWithHelp{Data: "Mod4-Control-Shift-j", Help: "Swap Down"},
+		SwapUp:                  StringWithHelp{Data: "Mod4-Control-Shift-k", Help: "Swap Up"},
+		SwapRight:               StringWithHelp{Data: "Mod4-Control-Shift-l", Help: "Swap Right"},
+		ResizeMode:              StringWithHelp{Data: "Mod4-a", Help: "Resize Mode"},
+		RotateSplit:             StringWithHelp{Data: "Mod4-Shift-e", Help: "Rotate Split"},
+		FlipSplit:               StringWithHelp{Data: "Mod4-Shift-f", Help: "Flip Split"},
+		EqualizeSplits:          StringWithHelp{Data: "Mod4-equal", Help: "Equalize Splits"},
+		BalanceSplits:           StringWithHelp{Data: "Mod4-Shift-equal", Help: "Balance Splits"},
+		UndoLayout:              StringWithHelp{Data: "Mod4-u", Help: "Undo Layout"},
+		RedoLayout:              StringWithHelp{Data: "Mod4-Shift-u", Help: "Redo Layout"},
+		UndoLimit:               50,
 		Backlight:               "intel_backlight",
 		ElemSize:                10,
 		CloseCursor:             xcursor.Dot,
@@ -192,10 +246,17 @@ func DefaultConfig() Config {
 		TaskbarMinMaxColor:        0x999999,
 		TaskbarSlideLeft:          "Mod4-Shift-left",
 		TaskbarSlideRight:         "Mod4-Shift-right",
+		TaskbarWorkspaceColor:       0x333333,
+		TaskbarWorkspaceActiveColor: 0x666666,
+		TaskbarWorkspaceUrgentColor: 0xcc5500,
 		CutSelectFrame:            "Mod4-c",
 		CutSelectContainer:        "Mod4-Shift-c",
 		CopySelectHorizontal:      StringWithHelp{Data: "Mod4-v", Help: "Paste Horizontally"},
 		CopySelectVertical:        StringWithHelp{Data: "Mod4-b", Help: "Paste Vertically"},
+		CopySelectTabbed:          StringWithHelp{Data: "Mod4-g", Help: "Paste As Tab"},
+		TabHeight:                 20,
+		TabActiveColor:            0x666666,
+		TabInactiveColor:          0x333333,
 		SuspendCommand:            "systemctl suspend",
 		BatteryWarningLevels:      []int{20, 10, 5, 1},
 		BatteryWarningDuration:    time.Second * 2,
@@ -212,6 +273,21 @@ func DefaultConfig() Config {
 			"Mod4-Shift-8": "Mod4-8",
 			"Mod4-Shift-9": "Mod4-9",
 		},
+		ReloadConfig: StringWithHelp{Data: "Mod4-Shift-r", Help: "Reload Config"},
+		Restart:      StringWithHelp{Data: "Mod4-Shift-BackSpace", Help: "Restart rowm"},
+		Layouts:       map[string]Layout{},
+		LaunchLayout:  StringWithHelp{Data: "Mod4-Shift-l", Help: "Launch Layout"},
+		LayoutTimeout: time.Second * 30,
+		WorkspaceKeys: []string{
+			"Mod4-F1", "Mod4-F2", "Mod4-F3", "Mod4-F4", "Mod4-F5", "Mod4-F6",
+			"Mod4-F7", "Mod4-F8", "Mod4-F9", "Mod4-F10", "Mod4-F11", "Mod4-F12",
+		},
+		MoveToWorkspaceKeys: []string{
+			"Mod4-Shift-F1", "Mod4-Shift-F2", "Mod4-Shift-F3", "Mod4-Shift-F4", "Mod4-Shift-F5", "Mod4-Shift-F6",
+			"Mod4-Shift-F7", "Mod4-Shift-F8", "Mod4-Shift-F9", "Mod4-Shift-F10", "Mod4-Shift-F11", "Mod4-Shift-F12",
+		},
+		MarkScratchpad:   StringWithHelp{Data: "Mod4-Shift-minus", Help: "Move To/From Scratchpad"},
+		ToggleScratchpad: StringWithHelp{Data: "Mod4-minus", Help: "Toggle Scratchpad"},
 	}
 }
 
@@ -0,0 +1,312 @@
+package frame
+
+import (
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// KeyBinding pairs a key string from the config with the setting it came from.
+type KeyBinding struct {
+	Field string
+	Key   string
+}
+
+// BuiltinCommand is how a builtin command is written in the config file.
+// It can also be written as a bare string, in which case it is just the command.
+type BuiltinCommand struct {
+	Help    string
+	Command string
+}
+
+// keyFields are the plain string settings that hold key strings.
+// StringWithHelp settings are always key strings so they are found by type instead.
+var keyFields = []string{
+	"Lock",
+	"Shutdown",
+	"ToggleExternalDecorator",
+	"ToggleTaskbar",
+	"ResetSize",
+	"Minimize",
+	"VolumeUp",
+	"VolumeDown",
+	"BrightnessUp",
+	"BrightnessDown",
+	"VolumeMute",
+	"TaskbarSlideLeft",
+	"TaskbarSlideRight",
+	"CutSelectFrame",
+	"CutSelectContainer",
+	"LaunchHelp",
+}
+
+var stringWithHelpType = reflect.TypeOf(StringWithHelp{})
+
+// ConfigPath is where the user config file is read from.
+func ConfigPath() string {
+	return path.Join(HomeDir(), ".config/rowm/config.toml")
+}
+
+// LoadConfig reads a TOML file and overlays it on top of DefaultConfig.
+// Only the settings present in the file are changed, a missing file just returns the defaults.
+func LoadConfig(filename string) (Config, error) {
+	conf := DefaultConfig()
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return conf, nil
+	}
+
+	var raw map[string]toml.Primitive
+	md, err := toml.DecodeFile(filename, &raw)
+	if err != nil {
+		return conf, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	v := reflect.ValueOf(&conf).Elem()
+	for key, prim := range raw {
+		switch key {
+		case "BuiltinCommands":
+			err = decodeBuiltinCommands(&md, prim, conf.BuiltinCommands)
+		case "GotoKeys":
+			err = decodeGotoKeys(&md, prim, conf.GotoKeys)
+		default:
+			field := v.FieldByName(key)
+			if !field.IsValid() {
+				return conf, fmt.Errorf("%s: unknown key %q", filename, key)
+			}
+			if field.Type() == stringWithHelpType && md.Type(key) == "String" {
+				// Allow just rebinding the key without having to spell out the help as well
+				err = md.PrimitiveDecode(prim, &conf.stringWithHelp(key).Data)
+			} else {
+				err = md.PrimitiveDecode(prim, field.Addr().Interface())
+			}
+		}
+		if err != nil {
+			return conf, fmt.Errorf("%s: %s: %v", filename, key, err)
+		}
+	}
+
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return conf, fmt.Errorf("%s: unknown key %q", filename, undecoded[0].String())
+	}
+
+	for name, l := range conf.Layouts {
+		if err := l.Check(); err != nil {
+			return conf, fmt.Errorf("%s: Layouts.%s: %v", filename, name, err)
+		}
+	}
+
+	for _, kb := range conf.KeyBindings() {
+		if err := CheckKeyString(kb.Key); err != nil {
+			return conf, fmt.Errorf("%s: %s: %v", filename, kb.Field, err)
+		}
+	}
+
+	return conf, nil
+}
+
+func (c *Config) stringWithHelp(field string) *StringWithHelp {
+	return reflect.ValueOf(c).Elem().FieldByName(field).Addr().Interface().(*StringWithHelp)
+}
+
+// decodeBuiltinCommands overlays the file's builtin commands on the defaults.
+// Entries are keyed by their key string, and an empty command removes the builtin.
+func decodeBuiltinCommands(md *toml.MetaData, prim toml.Primitive, cmds map[StringWithHelp]string) error {
+	var entries map[string]toml.Primitive
+	if err := md.PrimitiveDecode(prim, &entries); err != nil {
+		return err
+	}
+
+	for key, eprim := range entries {
+		var entry BuiltinCommand
+		if md.Type("BuiltinCommands", key) == "String" {
+			if err := md.PrimitiveDecode(eprim, &entry.Command); err != nil {
+				return err
+			}
+		} else if err := md.PrimitiveDecode(eprim, &entry); err != nil {
+			return err
+		}
+
+		for existing := range cmds {
+			if existing.Data == key {
+				if entry.Help == "" {
+					entry.Help = existing.Help
+				}
+				delete(cmds, existing)
+			}
+		}
+		if entry.Command == "" {
+			continue
+		}
+		if entry.Help == "" {
+			entry.Help = entry.Command
+		}
+		cmds[StringWithHelp{Data: key, Help: entry.Help}] = entry.Command
+	}
+	return nil
+}
+
+// decodeGotoKeys overlays the file's goto keys on the defaults, an empty value removes the goto.
+func decodeGotoKeys(md *toml.MetaData, prim toml.Primitive, gotos map[string]string) error {
+	var entries map[string]string
+	if err := md.PrimitiveDecode(prim, &entries); err != nil {
+		return err
+	}
+
+	for k, v := range entries {
+		if v == "" {
+			delete(gotos, k)
+		} else {
+			gotos[k] = v
+		}
+	}
+	return nil
+}
+
+// KeyBindings lists every key string in the config, sorted by the setting they came from.
+func (c *Config) KeyBindings() []KeyBinding {
+	bindings := make([]KeyBinding, 0)
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Type() == stringWithHelpType {
+			bindings = append(bindings, KeyBinding{
+				Field: v.Type().Field(i).Name,
+				Key:   v.Field(i).Interface().(StringWithHelp).Data,
+			})
+		}
+	}
+	for _, name := range keyFields {
+		bindings = append(bindings, KeyBinding{Field: name, Key: v.FieldByName(name).String()})
+	}
+	for k := range c.BuiltinCommands {
+		bindings = append(bindings, KeyBinding{Field: fmt.Sprintf("BuiltinCommands[%s]", k.Help), Key: k.Data})
+	}
+	for k, v := range c.GotoKeys {
+		bindings = append(bindings, KeyBinding{Field: "GotoKeys", Key: k})
+		bindings = append(bindings, KeyBinding{Field: fmt.Sprintf("GotoKeys[%s]", k), Key: v})
+	}
+	for i, k := range c.WorkspaceKeys {
+		bindings = append(bindings, KeyBinding{Field: fmt.Sprintf("WorkspaceKeys[%d]", i), Key: k})
+	}
+	for i, k := range c.MoveToWorkspaceKeys {
+		bindings = append(bindings, KeyBinding{Field: fmt.Sprintf("MoveToWorkspaceKeys[%d]", i), Key: k})
+	}
+	for name, l := range c.Layouts {
+		if l.Key != "" {
+			bindings = append(bindings, KeyBinding{Field: fmt.Sprintf("Layouts[%s]", name), Key: l.Key})
+		}
+	}
+
+	sort.SliceStable(bindings, func(i, j int) bool {
+		if bindings[i].Field == bindings[j].Field {
+			return bindings[i].Key < bindings[j].Key
+		}
+		return bindings[i].Field < bindings[j].Field
+	})
+	return bindings
+}
+
+// ResolveKeyBindings makes sure every key string can be found in the current keymap.
+// keybind must be initialized before calling this.
+func (c *Config) ResolveKeyBindings(X *xgbutil.XUtil) error {
+	for _, kb := range c.KeyBindings() {
+		if _, _, err := keybind.ParseString(X, kb.Key); err != nil {
+			return fmt.Errorf("%s: %v", kb.Field, err)
+		}
+	}
+	return nil
+}
+
+// CheckKeyBindings reports every problem with the key strings in the config,
+// including keys that are bound by more than one setting.
+// If X is nil keys are compared by name, otherwise they are resolved against the current keymap.
+func (c *Config) CheckKeyBindings(X *xgbutil.XUtil) []error {
+	problems := make([]error, 0)
+	bound := make(map[string][]KeyBinding)
+	order := make([]string, 0)
+	for _, kb := range c.KeyBindings() {
+		if err := CheckKeyString(kb.Key); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %v", kb.Field, err))
+			continue
+		}
+
+		id := NormalizeKeyString(kb.Key)
+		if X != nil {
+			mods, codes, err := keybind.ParseString(X, kb.Key)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s: %v", kb.Field, err))
+				continue
+			}
+			id = fmt.Sprint(mods, codes)
+		}
+
+		if _, ok := bound[id]; !ok {
+			order = append(order, id)
+		}
+		bound[id] = append(bound[id], kb)
+	}
+
+	for _, id := range order {
+		if len(bound[id]) < 2 {
+			continue
+		}
+		fields := make([]string, 0, len(bound[id]))
+		keys := make([]string, 0, len(bound[id]))
+		for _, kb := range bound[id] {
+			fields = append(fields, kb.Field)
+			if len(keys) == 0 || keys[len(keys)-1] != kb.Key {
+				keys = append(keys, kb.Key)
+			}
+		}
+		problems = append(problems, fmt.Errorf("%s is bound more than once: %s", strings.Join(keys, "/"), strings.Join(fields, ", ")))
+	}
+	return problems
+}
+
+// NormalizeKeyString puts a key string in a canonical form so two ways of writing a key compare equal.
+func NormalizeKeyString(s string) string {
+	mods := make([]string, 0)
+	key := ""
+	for _, part := range strings.Split(s, "-") {
+		switch lower := strings.ToLower(part); lower {
+		case "shift", "lock", "control", "mod1", "mod2", "mod3", "mod4", "mod5", "any":
+			mods = append(mods, lower)
+		default:
+			key = lower
+		}
+	}
+	sort.Strings(mods)
+	return strings.Join(append(mods, key), "-")
+}
+
+// CheckKeyString makes sure a key string is of the form '[Mod[-Mod[...]]]-KEY'.
+// Whether KEY exists on the keyboard can only be known once connected to X.
+func CheckKeyString(s string) error {
+	if s == "" {
+		return fmt.Errorf("empty key string")
+	}
+
+	key := ""
+	for _, part := range strings.Split(s, "-") {
+		switch strings.ToLower(part) {
+		case "shift", "lock", "control", "mod1", "mod2", "mod3", "mod4", "mod5", "any":
+		case "":
+			return fmt.Errorf("malformed key string %q", s)
+		default:
+			if key != "" {
+				return fmt.Errorf("key string %q has more than one key (%s and %s)", s, key, part)
+			}
+			key = part
+		}
+	}
+
+	if key == "" {
+		return fmt.Errorf("key string %q has no key", s)
+	}
+	return nil
+}
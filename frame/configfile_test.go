@@ -0,0 +1,193 @@
+package frame
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, contents string) string {
+	dir, err := ioutil.TempDir("", "rowm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	filename := path.Join(dir, "config.toml")
+	if err := ioutil.WriteFile(filename, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	conf, err := LoadConfig(path.Join(os.TempDir(), "rowm-does-not-exist.toml"))
+	if err != nil {
+		t.Fatalf("missing file: %v", err)
+	}
+	if conf.Lock != DefaultConfig().Lock || conf.ElemSize != DefaultConfig().ElemSize {
+		t.Errorf("missing file did not give the defaults")
+	}
+}
+
+func TestLoadConfigOverlay(t *testing.T) {
+	conf, err := LoadConfig(writeConfig(t, `
+Lock = "Mod4-Shift-l"
+ElemSize = 6
+SplitVertical = "Mod4-Shift-v"
+
+[BuiltinCommands]
+"Mod4-t" = "xterm"
+"Mod4-w" = ""
+"Mod4-e" = { Help = "Editor", Command = "gvim" }
+
+[GotoKeys]
+"Mod4-Shift-0" = ""
+"Mod4-Shift-F1" = "Mod4-F1"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defaults := DefaultConfig()
+
+	if conf.Lock != "Mod4-Shift-l" || conf.ElemSize != 6 {
+		t.Errorf("settings in the file were not applied: Lock %q, ElemSize %d", conf.Lock, conf.ElemSize)
+	}
+	if conf.Shell != defaults.Shell || conf.FocusColor != defaults.FocusColor {
+		t.Errorf("settings missing from the file were not left at their defaults")
+	}
+	if conf.SplitVertical.Data != "Mod4-Shift-v" || conf.SplitVertical.Help != defaults.SplitVertical.Help {
+		t.Errorf("rebinding a key lost its help: %+v", conf.SplitVertical)
+	}
+
+	commands := make(map[string]StringWithHelp)
+	for k := range conf.BuiltinCommands {
+		commands[k.Data] = k
+	}
+	if k, ok := commands["Mod4-t"]; !ok || conf.BuiltinCommands[k] != "xterm" || k.Help != "Terminal" {
+		t.Errorf("builtin command was not overridden with its help kept: %+v", k)
+	}
+	if _, ok := commands["Mod4-w"]; ok {
+		t.Errorf("builtin command with an empty command was not removed")
+	}
+	if k, ok := commands["Mod4-e"]; !ok || conf.BuiltinCommands[k] != "gvim" || k.Help != "Editor" {
+		t.Errorf("new builtin command was not added: %+v", k)
+	}
+	if _, ok := commands["Mod4-p"]; !ok {
+		t.Errorf("builtin command missing from the file was removed")
+	}
+
+	if _, ok := conf.GotoKeys["Mod4-Shift-0"]; ok {
+		t.Errorf("goto key with an empty value was not removed")
+	}
+	if conf.GotoKeys["Mod4-Shift-F1"] != "Mod4-F1" || conf.GotoKeys["Mod4-Shift-1"] != "Mod4-1" {
+		t.Errorf("goto keys were not overlaid on the defaults: %v", conf.GotoKeys)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	cases := []struct {
+		name     string
+		contents string
+		want     string
+	}{
+		{"unknown key", `NotASetting = 1`, `unknown key "NotASetting"`},
+		{"unknown nested key", "[BuiltinCommands]\n\"Mod4-e\" = { Help = \"Editor\", Cmd = \"gvim\" }", "unknown key"},
+		{"wrong type", `ElemSize = "big"`, "ElemSize"},
+		{"malformed key string", `Lock = "Mod4--l"`, "Lock: malformed key string"},
+		{"two keys", `Minimize = "Mod4-a-b"`, "Minimize: key string"},
+		{"no key", `SplitVertical = "Mod4-Shift"`, "SplitVertical: key string"},
+		{"bad toml", `Lock = `, "config.toml"},
+	}
+	for _, c := range cases {
+		conf, err := LoadConfig(writeConfig(t, c.contents))
+		if err == nil {
+			t.Errorf("%s: no error", c.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error %q does not mention %q", c.name, err, c.want)
+		}
+		if conf.ElemSize == 0 {
+			t.Errorf("%s: defaults were not returned along with the error", c.name)
+		}
+	}
+}
+
+func TestCheckKeyString(t *testing.T) {
+	cases := []struct {
+		key string
+		ok  bool
+	}{
+		{"Mod4-l", true},
+		{"Mod4-Shift-Return", true},
+		{"control-Mod1-Delete", true},
+		{"F5", true},
+		{"", false},
+		{"Mod4-", false},
+		{"Mod4--l", false},
+		{"Mod4-Shift", false},
+		{"Mod4-a-b", false},
+	}
+	for _, c := range cases {
+		if err := CheckKeyString(c.key); (err == nil) != c.ok {
+			t.Errorf("CheckKeyString(%q) = %v", c.key, err)
+		}
+	}
+}
+
+func TestNormalizeKeyString(t *testing.T) {
+	cases := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"Mod4-Shift-l", "Shift-Mod4-l", true},
+		{"mod4-SHIFT-Return", "Mod4-Shift-return", true},
+		{"Mod4-l", "Mod4-Shift-l", false},
+		{"Mod4-l", "Mod1-l", false},
+		{"Mod4-l", "Mod4-k", false},
+	}
+	for _, c := range cases {
+		if equal := NormalizeKeyString(c.a) == NormalizeKeyString(c.b); equal != c.equal {
+			t.Errorf("%q and %q normalized equal = %v, want %v", c.a, c.b, equal, c.equal)
+		}
+	}
+}
+
+func TestCheckKeyBindings(t *testing.T) {
+	defaults := DefaultConfig()
+	if problems := defaults.CheckKeyBindings(nil); len(problems) > 0 {
+		t.Errorf("default config has problems: %v", problems)
+	}
+
+	cases := []struct {
+		name   string
+		change func(c *Config)
+		want   []string
+	}{
+		{"same key twice", func(c *Config) { c.Minimize = c.Lock }, []string{"Lock", "Minimize"}},
+		{"written another way", func(c *Config) {
+			c.Lock = "Mod4-Shift-x"
+			c.Minimize = "shift-mod4-X"
+		}, []string{"Lock", "Minimize"}},
+		{"builtin command", func(c *Config) {
+			c.BuiltinCommands[StringWithHelp{Data: c.Lock, Help: "Locker"}] = "true"
+		}, []string{"Lock", "BuiltinCommands[Locker]"}},
+		{"bad key string", func(c *Config) { c.Lock = "Mod4--l" }, []string{"Lock: malformed key string"}},
+	}
+	for _, c := range cases {
+		conf := DefaultConfig()
+		c.change(&conf)
+		problems := conf.CheckKeyBindings(nil)
+		if len(problems) != 1 {
+			t.Errorf("%s: got %d problems, want 1: %v", c.name, len(problems), problems)
+			continue
+		}
+		for _, want := range c.want {
+			if !strings.Contains(problems[0].Error(), want) {
+				t.Errorf("%s: problem %q does not mention %q", c.name, problems[0], want)
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/BurntSushi/xgbutil/xwindow"
 	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
 	"log"
 	"time"
 )
@@ -26,9 +27,38 @@ type Container struct {
 	Hidden              bool
 	LastUnanchoredShape Rect
 	LastGrabTime        time.Time
+	WmState             WindowState
+	Floating            bool       // Dialogs and the like, which have no decorations and are left out of the taskbar
+	TransientFor        *Container // The container a floating container belongs to (if any)
+	Workspace           int        // Workspace on its screen the container is on
+	Scratchpad          bool       // Kept out of the taskbar and only shown by toggling the scratchpad
 }
 
 func (c *Container) Raise(ctx *Context) {
+	// Bringing up a container on another workspace goes to that workspace
+	if !c.OnCurrentWorkspace(ctx) {
+		ctx.ShowWorkspace(ctx.ScreenIndex(c), c.Workspace)
+	}
+	c.stack(ctx)
+	for oc := range ctx.Containers {
+		if oc.TransientFor == c && !oc.Hidden {
+			oc.stack(ctx)
+		}
+	}
+	// Fullscreen containers cover everything, otherwise containers that asked to be above and the scratchpad stay on top
+	if !c.WmState.Fullscreen {
+		for oc := range ctx.Containers {
+			if oc != c && (oc.WmState.Above || oc.Scratchpad) && !oc.Hidden {
+				oc.stack(ctx)
+			}
+		}
+		ctx.RaiseDocks()
+		ctx.Taskbar.Raise(ctx)
+	}
+	ctx.UpdateClientList()
+}
+
+func (c *Container) stack(ctx *Context) {
 	c.Decorations.ForEach(func(d *Decoration) {
 		d.Window.Stack(xproto.StackModeAbove)
 	})
@@ -39,7 +69,6 @@ func (c *Container) Raise(ctx *Context) {
 	c.Root.Traverse(func(f *Frame) {
 		f.RaiseDecoration(ctx)
 	})
-	ctx.Taskbar.Raise(ctx)
 }
 
 func (c *Container) ActiveRoot() *Frame {
@@ -95,23 +124,42 @@ func (c *Container) Map() {
 
 func (c *Container) ChangeMinimizationState(ctx *Context) {
 	c.Hidden = !c.Hidden
+	// Dialogs go along with the container they belong to
+	for oc := range ctx.Containers {
+		if oc.TransientFor == c && oc.Hidden != c.Hidden {
+			defer oc.ChangeMinimizationState(ctx)
+		}
+	}
 	c.UpdateFrameMappings(ctx)
 	if !c.Hidden {
 		c.RaiseFindFocus(ctx)
 	} else {
 		ext.Focus(xwindow.New(ctx.X, ctx.X.RootWin()))
+		ctx.SetActiveWindow(0)
 	}
 	ctx.Taskbar.UpdateContainer(ctx, c)
+	c.UpdateWmState(ctx)
+
+	if leaf := c.Root.Find(func(f *Frame) bool { return f.IsLeaf() }); leaf != nil {
+		if c.Hidden {
+			ctx.EmitWindow(ipc.EventMinimize, leaf.Window.Id)
+		} else {
+			ctx.EmitWindow(ipc.EventRestore, leaf.Window.Id)
+		}
+	}
 }
 
 func (c *Container) UpdateFrameMappings(ctx *Context) {
-	if c.Hidden {
+	if !c.Shown(ctx) {
 		c.Root.Unmap(ctx)
 		c.Decorations.Unmap()
 		return
 	}
 
 	if !c.Decorations.Hidden {
+		if !c.Decorations.Generated() {
+			ext.Logerr(GeneratePieces(ctx, c))
+		}
 		c.Decorations.Map()
 	} else {
 		c.Decorations.Unmap()
@@ -139,7 +187,14 @@ func (c *Container) MoveResize(ctx *Context, x, y, w, h int) {
 }
 
 func (c *Container) MoveResizeShape(ctx *Context, shape Rect) {
+	visible := c.OnCurrentWorkspace(ctx)
 	c.Shape = shape
+	// Containers moved onto another screen join the workspace shown there
+	if visible {
+		c.Workspace = ctx.CurrentWorkspace(ctx.ScreenIndex(c))
+	} else if c.OnCurrentWorkspace(ctx) {
+		c.UpdateFrameMappings(ctx)
+	}
 	c.ActiveRoot().MoveResize(ctx)
 	c.Decorations.MoveResize(ctx, c.Shape)
 }
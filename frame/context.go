@@ -13,6 +13,7 @@ import (
 	"github.com/BurntSushi/xgbutil/xwindow"
 	"github.com/google/goexpect"
 	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
 	"github.com/levavakian/rowm/sideloop"
 	"log"
 	"os/exec"
@@ -60,13 +61,29 @@ type Context struct {
 	LastLockChange         time.Time                         // Last time we went from locked->unlocked or reverse
 	Injector               *sideloop.Injector                // Utility for inserting work between X events
 	Gotos                  map[string]xproto.Window          // Mapping of shortcut minimize/focus keys for windows
+	PendingLayouts         []*PendingLayout                  // Launched layouts still waiting on some of their windows
+	Subscribers            map[chan ipc.Event]struct{}       // Listeners for events on the command socket
+	Clients                []xproto.Window                   // Managed windows in the order they were first managed
+	Stacking               []xproto.Window                   // Managed windows from bottom to top, as last published
+	ClientListStale        bool                              // The client lists need publishing again
+	Docks                  map[xproto.Window]*Dock           // Panels and other windows that sit outside of containers
+	Workspaces             []int                             // The workspace shown on each screen
+	WorkspaceFocus         map[WorkspaceId]xproto.Window     // Window to focus when going back to a workspace
+	WmDesktops             map[xproto.Window]int             // Last _NET_WM_DESKTOP published for each window
+	Scratchpad             []*Container                      // Containers in the scratchpad, next to be shown first
+	LayoutHistory          LayoutHistory                     // Earlier layouts to undo to, and undone ones to redo
+	ExitKeyboardGrab       func()                            // Leaves the mode that has the keyboard grabbed, nil if there is none
 }
 
 // NewContext will create a new context but also populate screen backgrounds, create the taskbar, and generate the cursor cache
 func NewContext(x *xgbutil.XUtil, inj *sideloop.Injector) (*Context, error) {
-	conf := DefaultConfig()
+	conf, err := LoadConfig(ConfigPath())
+	if err != nil {
+		log.Println("could not load config, falling back to defaults:", err)
+		conf = DefaultConfig()
+		err = nil
+	}
 
-	var err error
 	c := &Context{
 		X:              x,
 		Tracked:        make(map[xproto.Window]*Frame),
@@ -78,6 +95,10 @@ func NewContext(x *xgbutil.XUtil, inj *sideloop.Injector) (*Context, error) {
 		LastLockChange: time.Now(),
 		Injector:       inj,
 		Gotos:          make(map[string]xproto.Window),
+		Subscribers:    make(map[chan ipc.Event]struct{}),
+		Docks:          make(map[xproto.Window]*Dock),
+		WorkspaceFocus: make(map[WorkspaceId]xproto.Window),
+		WmDesktops:     make(map[xproto.Window]int),
 	}
 	c.UpdateScreens()
 	c.Taskbar = NewTaskbar(c)
@@ -94,6 +115,55 @@ func NewContext(x *xgbutil.XUtil, inj *sideloop.Injector) (*Context, error) {
 	return c, err
 }
 
+// ApplyConfig swaps in a new config, updating the look of everything already on screen
+// and reconnecting the key bindings of every window. Root key bindings are left to the caller.
+func (ctx *Context) ApplyConfig(conf Config) {
+	old := ctx.Config
+	// Mouse bindings are grabbed once per decoration and window, so they only change on restart
+	mouse := [...]*string{&conf.ButtonDrag, &conf.ButtonClick, &conf.ButtonDragFrame, &conf.ButtonMoveContainer, &conf.ButtonResizeContainer}
+	oldMouse := [...]string{old.ButtonDrag, old.ButtonClick, old.ButtonDragFrame, old.ButtonMoveContainer, old.ButtonResizeContainer}
+	for i, button := range mouse {
+		if *button != oldMouse[i] {
+			log.Println("mouse binding", *button, "is used after a restart, keeping", oldMouse[i])
+			*button = oldMouse[i]
+		}
+	}
+	ctx.Config = conf
+
+	if conf.TaskbarElementShape != old.TaskbarElementShape {
+		ctx.DummyIcon = xgraphics.New(ctx.X, conf.TaskbarElementShape.ToImageRect())
+	}
+	if conf.BackgroundImagePath != old.BackgroundImagePath {
+		ext.Logerr(GenerateBackgrounds(ctx))
+	}
+
+	for window := range ctx.Tracked {
+		wref := window // capture separately so we can use in closure
+		err := ext.RebindKeys(ctx.X, func() error {
+			return AddWindowKeyHooks(ctx, wref)
+		}, window)
+		ext.Logerr(err)
+	}
+
+	ctx.ClampWorkspaces()
+	ctx.UpdateWorkspaceHints()
+	ctx.UpdateWorkArea()
+	for c := range ctx.Containers {
+		c.UpdateColors(ctx)
+		c.MoveResizeShape(ctx, c.Shape)
+		c.UpdateFrameMappings(ctx)
+	}
+
+	ctx.Taskbar.UpdateColors(ctx)
+	ctx.Taskbar.MoveResize(ctx)
+	ctx.Taskbar.UpdateMapping(ctx)
+	for c := range ctx.Containers {
+		ctx.Taskbar.UpdateContainer(ctx, c)
+	}
+	ctx.Taskbar.Update(ctx)
+	ctx.RaiseLock()
+}
+
 func (ctx *Context) GenerateLockPrompt() {
 	theme := *prompt.DefaultInputTheme
 	theme.Font = NoFont
@@ -185,6 +255,11 @@ func (ctx *Context) SetLocked(state bool) {
 	}
 	ctx.Locked = state
 	if ctx.Locked {
+		// The lock prompt needs the keyboard to itself
+		if ctx.ExitKeyboardGrab != nil {
+			ctx.ExitKeyboardGrab()
+		}
+		ctx.Emit(ipc.Event{Type: ipc.EventLock})
 		ctx.RaiseLock()
 		err := exec.Command("bash", "-c", ctx.Config.SuspendCommand).Run()
 		if err != nil {
@@ -192,6 +267,7 @@ func (ctx *Context) SetLocked(state bool) {
 		}
 	} else {
 		ctx.LowerLock()
+		ctx.Emit(ipc.Event{Type: ipc.EventUnlock})
 	}
 }
 
@@ -245,7 +321,14 @@ func (ctx *Context) UpdateScreens() {
 	if ctx.Taskbar != nil {
 		ctx.Taskbar.MoveResize(ctx)
 	}
+	ctx.UpdateWorkArea()
 	ctx.RaiseLock()
+
+	ev := ipc.Event{Type: ipc.EventScreens}
+	for _, s := range screens {
+		ev.Screens = append(ev.Screens, ipc.Rect{X: s.X, Y: s.Y, W: s.W, H: s.H})
+	}
+	ctx.Emit(ev)
 }
 
 func (c *Context) Get(w xproto.Window) *Frame {
@@ -269,14 +352,15 @@ func (ctx *Context) GetScreenForShape(shape Rect) (Rect, int, int) {
 }
 
 func (ctx *Context) DefaultShapeForScreen(screen Rect) Rect {
+	area := ctx.WorkArea(screen)
 	osize := ctx.Config.ElemSize * 2
 	offset := 0
 	for {
 		s := Rect{
-			X: screen.X + int(ctx.Config.DefaultShapeRatio.X*float64(screen.W)) + offset*osize,
-			Y: screen.Y + int(ctx.Config.DefaultShapeRatio.Y*float64(screen.H)) + offset*osize,
-			W: int(ctx.Config.DefaultShapeRatio.W * float64(screen.W)),
-			H: int(ctx.Config.DefaultShapeRatio.H * float64(screen.H)),
+			X: area.X + int(ctx.Config.DefaultShapeRatio.X*float64(area.W)) + offset*osize,
+			Y: area.Y + int(ctx.Config.DefaultShapeRatio.Y*float64(area.H)) + offset*osize,
+			W: int(ctx.Config.DefaultShapeRatio.W * float64(area.W)),
+			H: int(ctx.Config.DefaultShapeRatio.H * float64(area.H)),
 		}
 		tshape := TopShape(ctx, s)
 		tscreen, overlap, _ := ctx.GetScreenForShape(tshape)
@@ -297,10 +381,10 @@ func (ctx *Context) DefaultShapeForScreen(screen Rect) Rect {
 		offset++
 	}
 	return Rect{
-		X: screen.X + int(ctx.Config.DefaultShapeRatio.X*float64(screen.W)),
-		Y: screen.Y + int(ctx.Config.DefaultShapeRatio.Y*float64(screen.H)),
-		W: int(ctx.Config.DefaultShapeRatio.W * float64(screen.W)),
-		H: int(ctx.Config.DefaultShapeRatio.H * float64(screen.H)),
+		X: area.X + int(ctx.Config.DefaultShapeRatio.X*float64(area.W)),
+		Y: area.Y + int(ctx.Config.DefaultShapeRatio.Y*float64(area.H)),
+		W: int(ctx.Config.DefaultShapeRatio.W * float64(area.W)),
+		H: int(ctx.Config.DefaultShapeRatio.H * float64(area.H)),
 	}
 }
 
@@ -11,12 +11,13 @@ type PartitionType int
 const (
 	HORIZONTAL PartitionType = iota
 	VERTICAL
+	TABBED // children are stacked as tabs that share the same space
 )
 
 type Partition struct {
-	Ratio      float64
-	Type       PartitionType
-	Decoration Decoration
+	Type        PartitionType
+	Weights     []float64    // Share of the space given to each child, adding up to 1
+	Decorations []Decoration // Separators between each pair of children, or the tab strip of a tab group
 }
 
 type Decoration struct {
@@ -57,7 +58,21 @@ func (d *Decoration) MoveResize(r Rect) {
 	d.Window.MoveResize(r.X, r.Y, r.W, r.H)
 }
 
+// SetColor changes the background of the decoration and repaints it.
+func (d *Decoration) SetColor(color uint32) {
+	d.Window.Change(xproto.CwBackPixel, color)
+	d.Window.ClearAll()
+}
+
+// Generated checks if the decoration windows have been made, floating containers only get them once they are shown.
+func (cd *ContainerDecorations) Generated() bool {
+	return cd.Grab.Window != nil
+}
+
 func (cd *ContainerDecorations) ForEach(f func(*Decoration)) {
+	if !cd.Generated() {
+		return
+	}
 	f(&cd.Close)
 	f(&cd.Minimize)
 	f(&cd.Maximize)
@@ -80,6 +95,9 @@ func (cd *ContainerDecorations) Destroy(ctx *Context) {
 }
 
 func (cd *ContainerDecorations) MoveResize(ctx *Context, cShape Rect) {
+	if !cd.Generated() {
+		return
+	}
 	cd.Close.MoveResize(CloseShape(ctx, cShape))
 	cd.Grab.MoveResize(GrabShape(ctx, cShape))
 	cd.Top.MoveResize(TopShape(ctx, cShape))
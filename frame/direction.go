@@ -0,0 +1,100 @@
+package frame
+
+import (
+	"github.com/levavakian/rowm/ext"
+	"log"
+)
+
+// facing turns a shape so that looking in a direction becomes looking to the right.
+func facing(r Rect, dir AnchorType) Rect {
+	switch dir {
+	case LEFT:
+		return Rect{X: -r.X - r.W, Y: r.Y, W: r.W, H: r.H}
+	case BOTTOM:
+		return Rect{X: r.Y, Y: r.X, W: r.H, H: r.W}
+	case TOP:
+		return Rect{X: -r.Y - r.H, Y: r.X, W: r.H, H: r.W}
+	}
+	return r
+}
+
+// NearestInDirection returns the frame closest to a shape in a direction, nil if none are that way.
+// Frames lined up with the shape win over ones off to the side, then the closest one wins.
+func NearestInDirection(from Rect, frames []*Frame, dir AnchorType) *Frame {
+	f := facing(from, dir)
+	var best *Frame
+	var bestAside bool
+	var bestDist, bestOffset int
+	for _, fr := range frames {
+		t := facing(fr.Shape, dir)
+		// It has to reach past the far edge, and be centered past the middle
+		if t.X+t.W <= f.X+f.W || 2*t.X+t.W <= 2*f.X+f.W {
+			continue
+		}
+		aside := t.Y >= f.Y+f.H || t.Y+t.H <= f.Y
+		dist := ext.IMax(t.X-(f.X+f.W), 0)
+		offset := ext.IAbs((2*t.Y + t.H) - (2*f.Y + f.H))
+		better := best == nil ||
+			(bestAside && !aside) ||
+			(bestAside == aside && (dist < bestDist || (dist == bestDist && offset < bestOffset)))
+		if better {
+			best, bestAside, bestDist, bestOffset = fr, aside, dist, offset
+		}
+	}
+	return best
+}
+
+// Neighbor returns the closest mapped leaf in a direction, looking in the frame's own container first
+// and then in every shown container on any screen.
+func (f *Frame) Neighbor(ctx *Context, dir AnchorType) *Frame {
+	if f.IsOrphan() {
+		return nil
+	}
+	leaves := func(c *Container) []*Frame {
+		found := make([]*Frame, 0)
+		c.Root.Traverse(func(fr *Frame) {
+			if fr != f && fr.IsLeaf() && fr.Mapped {
+				found = append(found, fr)
+			}
+		})
+		return found
+	}
+
+	if n := NearestInDirection(f.Shape, leaves(f.Container), dir); n != nil {
+		return n
+	}
+	others := make([]*Frame, 0)
+	for c := range ctx.Containers {
+		if c == f.Container || c.Root == nil || !c.Shown(ctx) {
+			continue
+		}
+		others = append(others, leaves(c)...)
+	}
+	return NearestInDirection(f.Shape, others, dir)
+}
+
+// SwapWindow exchanges the windows of two mapped leaves, the frames and their ratios stay where they are.
+func (f *Frame) SwapWindow(ctx *Context, other *Frame) {
+	if other == nil || f == other || f.IsOrphan() || other.IsOrphan() || !f.IsLeaf() || !other.IsLeaf() {
+		return
+	}
+	if !f.Mapped || !other.Mapped || f.Container.Floating || other.Container.Floating {
+		log.Println("can only swap windows of mapped frames that are not floating")
+		return
+	}
+	ctx.RecordLayout()
+
+	f.Window, other.Window = other.Window, f.Window
+	f.Hints, other.Hints = other.Hints, f.Hints
+	f.Urgent, other.Urgent = other.Urgent, f.Urgent
+	ctx.Tracked[f.Window.Id] = f
+	ctx.Tracked[other.Window.Id] = other
+
+	for _, fr := range []*Frame{f, other} {
+		fr.MoveResize(ctx)
+		fr.Container.UpdateTabs(ctx)
+		fr.Container.UpdateWmState(ctx)
+		ctx.Taskbar.UpdateContainer(ctx, fr.Container)
+	}
+	ctx.UpdateClientList()
+}
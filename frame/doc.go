@@ -6,11 +6,28 @@ frame.go - defines the tree structure and traversal of windows
 container.go - defines the resizing, minimizing, and moving of a window tree as wrapped by decorations
 context.go - all non trivial state is stored in the context, and is available to most operations
 config.go - store of all user defined settings
+configfile.go - loading user overrides of the default settings from a config file
 decoration.go - utilities for decorations (non user created windows)
 pieces.go - definitions of individual decorations and their callbacks which make up a container
 taskbar.go - a taskbar decoration for displaying basic system information and showing open windows
 anchor.go - utilities for defining screen anchors (preset shapes on a screen you can hotkey to)
 background.go - utilities for generating backgrounds
 rect.go - a basic rectangle definition for describing window shapes and locations
+state.go - saving and restoring the layout of every container across restarts
+layout.go - launching layouts from the config and placing their windows as they show up
+events.go - sending events to command socket subscribers
+ewmh.go - publishing window manager state in the EWMH root window properties
+floating.go - floating dialogs and other transient windows over the window they belong to
+hints.go - keeping windows within the size constraints they set in WM_NORMAL_HINTS
+dock.go - panels and docks that sit outside of containers, and the work area they leave for everything else
+workspace.go - switching between workspaces on each screen and moving containers between them
+scratchpad.go - the scratchpad of hidden containers that can be summoned onto any screen
+direction.go - finding the closest frame in a direction, for moving focus and swapping windows around
+arrange.go - rotating, flipping, equalizing and balancing the splits of a frame tree
+resize.go - growing and shrinking frames and containers one step at a time from the keyboard
+dragdrop.go - dragging frames with the mouse onto other frames or out onto the desktop
+history.go - snapshots of the layout of every container, for undoing and redoing layout changes
+tabs.go - tab groups of frames that share the same space, and the tab strip to switch between them
+wmstate.go - fullscreen, maximized and other states clients can ask for through _NET_WM_STATE
 */
 package frame
@@ -0,0 +1,223 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/BurntSushi/xgbutil/xprop"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+	"log"
+)
+
+func init() {
+	SupportedHints = append(SupportedHints,
+		"_NET_WM_WINDOW_TYPE_DOCK",
+		"_NET_WM_STRUT",
+		"_NET_WM_STRUT_PARTIAL",
+		"_NET_WORKAREA",
+	)
+}
+
+// Dock is a panel or other desktop component like polybar, plank or conky.
+// Docks are left undecorated outside of any container, and the space they reserve is kept clear of containers.
+type Dock struct {
+	Window *xwindow.Window
+	Strut  *ewmh.WmStrutPartial // Space reserved along the edges of the root window (if any)
+	Mapped bool
+}
+
+// IsDock checks whether a window is a dock, either by its window type or by it reserving space.
+func IsDock(ctx *Context, window xproto.Window) bool {
+	if _, ok := ctx.Docks[window]; ok {
+		return true
+	}
+	types, _ := ewmh.WmWindowTypeGet(ctx.X, window)
+	for _, t := range types {
+		if t == "_NET_WM_WINDOW_TYPE_DOCK" {
+			return true
+		}
+	}
+	return LoadStrut(ctx, window) != nil
+}
+
+// LoadStrut reads the space a window reserves, preferring _NET_WM_STRUT_PARTIAL over _NET_WM_STRUT.
+// Returns nil if the window doesn't reserve any.
+func LoadStrut(ctx *Context, window xproto.Window) *ewmh.WmStrutPartial {
+	sp, err := ewmh.WmStrutPartialGet(ctx.X, window)
+	if err != nil {
+		s, err := ewmh.WmStrutGet(ctx.X, window)
+		if err != nil {
+			return nil
+		}
+		sp = &ewmh.WmStrutPartial{Left: s.Left, Right: s.Right, Top: s.Top, Bottom: s.Bottom}
+	}
+	if sp.Left == 0 && sp.Right == 0 && sp.Top == 0 && sp.Bottom == 0 {
+		return nil
+	}
+	return sp
+}
+
+// Reserved returns the areas of the root window the dock reserves for each edge (LEFT, RIGHT, TOP, BOTTOM).
+func (d *Dock) Reserved(root Rect) map[AnchorType]Rect {
+	reserved := make(map[AnchorType]Rect)
+	if d.Strut == nil || !d.Mapped {
+		return reserved
+	}
+	s := d.Strut
+
+	// Ranges that are left unset cover the whole edge
+	span := func(start, end uint, full int) (int, int) {
+		if end == 0 || end < start {
+			return 0, full
+		}
+		return int(start), int(end) - int(start) + 1
+	}
+	if s.Left > 0 {
+		y, h := span(s.LeftStartY, s.LeftEndY, root.H)
+		reserved[LEFT] = Rect{X: root.X, Y: root.Y + y, W: int(s.Left), H: h}
+	}
+	if s.Right > 0 {
+		y, h := span(s.RightStartY, s.RightEndY, root.H)
+		reserved[RIGHT] = Rect{X: root.X + root.W - int(s.Right), Y: root.Y + y, W: int(s.Right), H: h}
+	}
+	if s.Top > 0 {
+		x, w := span(s.TopStartX, s.TopEndX, root.W)
+		reserved[TOP] = Rect{X: root.X + x, Y: root.Y, W: w, H: int(s.Top)}
+	}
+	if s.Bottom > 0 {
+		x, w := span(s.BottomStartX, s.BottomEndX, root.W)
+		reserved[BOTTOM] = Rect{X: root.X + x, Y: root.Y + root.H - int(s.Bottom), W: w, H: int(s.Bottom)}
+	}
+	return reserved
+}
+
+// RootShape returns the shape of the root window, which covers every screen.
+func (ctx *Context) RootShape() Rect {
+	if len(ctx.Screens) == 0 {
+		return Rect{}
+	}
+	x0, y0 := ctx.Screens[0].X, ctx.Screens[0].Y
+	x1, y1 := x0+ctx.Screens[0].W, y0+ctx.Screens[0].H
+	for _, s := range ctx.Screens[1:] {
+		x0, y0 = ext.IMin(x0, s.X), ext.IMin(y0, s.Y)
+		x1, y1 = ext.IMax(x1, s.X+s.W), ext.IMax(y1, s.Y+s.H)
+	}
+	return Rect{X: x0, Y: y0, W: x1 - x0, H: y1 - y0}
+}
+
+// WorkArea returns the part of a screen that is not taken up by the taskbar or reserved by docks.
+func (ctx *Context) WorkArea(screen Rect) Rect {
+	left, top := screen.X, screen.Y
+	right, bottom := screen.X+screen.W, screen.Y+screen.H
+	if ctx.Taskbar != nil && !ctx.Taskbar.Hidden && screen == ctx.Screens[0] {
+		bottom -= ctx.Config.TaskbarHeight
+	}
+
+	root := ctx.RootShape()
+	for _, d := range ctx.Docks {
+		for edge, r := range d.Reserved(root) {
+			if AreaOfIntersection(r, screen) == 0 {
+				continue
+			}
+			switch edge {
+			case LEFT:
+				left = ext.IMax(left, r.X+r.W)
+			case RIGHT:
+				right = ext.IMin(right, r.X)
+			case TOP:
+				top = ext.IMax(top, r.Y+r.H)
+			case BOTTOM:
+				bottom = ext.IMin(bottom, r.Y)
+			}
+		}
+	}
+	return Rect{X: left, Y: top, W: ext.IMax(right-left, 0), H: ext.IMax(bottom-top, 0)}
+}
+
+// UpdateWorkArea publishes the area covered by the work areas of every screen in _NET_WORKAREA, once for every workspace.
+func (ctx *Context) UpdateWorkArea() {
+	if len(ctx.Screens) == 0 {
+		return
+	}
+	area := ctx.WorkArea(ctx.Screens[0])
+	x0, y0, x1, y1 := area.X, area.Y, area.X+area.W, area.Y+area.H
+	for _, s := range ctx.Screens[1:] {
+		area = ctx.WorkArea(s)
+		x0, y0 = ext.IMin(x0, area.X), ext.IMin(y0, area.Y)
+		x1, y1 = ext.IMax(x1, area.X+area.W), ext.IMax(y1, area.Y+area.H)
+	}
+	areas := make([]ewmh.Workarea, ctx.NumWorkspaces())
+	for i := range areas {
+		areas[i] = ewmh.Workarea{X: x0, Y: y0, Width: uint(x1 - x0), Height: uint(y1 - y0)}
+	}
+	ext.Logerr(ewmh.WorkareaSet(ctx.X, areas))
+}
+
+// ManageDock shows a dock as is and makes room for it.
+func ManageDock(ctx *Context, window xproto.Window) {
+	d, ok := ctx.Docks[window]
+	if !ok {
+		d = &Dock{Window: xwindow.New(ctx.X, window)}
+		ctx.Docks[window] = d
+		AddDockHook(ctx, d)
+	}
+
+	KeepAnchored(ctx, func() {
+		d.Strut = LoadStrut(ctx, window)
+		d.Mapped = true
+	})
+	if err := ext.MapChecked(d.Window); err != nil {
+		log.Println("ManageDock:", window, "could not be mapped")
+	}
+	d.Window.Stack(xproto.StackModeAbove)
+}
+
+// AddDockHook follows a dock as it changes the space it reserves, hides and goes away.
+func AddDockHook(ctx *Context, d *Dock) {
+	window := d.Window.Id
+	xevent.ConfigureRequestFun(
+		func(X *xgbutil.XUtil, ev xevent.ConfigureRequestEvent) {
+			d.Window.MoveResize(int(ev.X), int(ev.Y), int(ev.Width), int(ev.Height))
+		}).Connect(ctx.X, window)
+
+	xevent.PropertyNotifyFun(
+		func(X *xgbutil.XUtil, ev xevent.PropertyNotifyEvent) {
+			name, err := xprop.AtomName(X, ev.Atom)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if name == "_NET_WM_STRUT" || name == "_NET_WM_STRUT_PARTIAL" {
+				KeepAnchored(ctx, func() {
+					d.Strut = LoadStrut(ctx, window)
+				})
+			}
+		}).Connect(ctx.X, window)
+	ext.Logerr(d.Window.Listen(xproto.EventMaskPropertyChange))
+
+	xevent.UnmapNotifyFun(
+		func(X *xgbutil.XUtil, ev xevent.UnmapNotifyEvent) {
+			KeepAnchored(ctx, func() {
+				d.Mapped = false
+			})
+		}).Connect(ctx.X, window)
+
+	xevent.DestroyNotifyFun(
+		func(X *xgbutil.XUtil, ev xevent.DestroyNotifyEvent) {
+			KeepAnchored(ctx, func() {
+				delete(ctx.Docks, window)
+			})
+			xevent.Detach(ctx.X, window)
+		}).Connect(ctx.X, window)
+}
+
+// RaiseDocks keeps docks above containers.
+func (ctx *Context) RaiseDocks() {
+	for _, d := range ctx.Docks {
+		if d.Mapped {
+			d.Window.Stack(xproto.StackModeAbove)
+		}
+	}
+}
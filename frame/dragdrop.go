@@ -0,0 +1,231 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/mousebind"
+	"github.com/BurntSushi/xgbutil/xcursor"
+	"github.com/levavakian/rowm/ext"
+	"log"
+)
+
+// DropPreview outlines the space a dragged frame will take once it is dropped.
+type DropPreview struct {
+	Sides [4]Decoration
+}
+
+// NewDropPreview creates the outline, it stays hidden until it is shown somewhere.
+func NewDropPreview(ctx *Context) (*DropPreview, error) {
+	p := &DropPreview{}
+	for i := range p.Sides {
+		d, err := CreateDecoration(ctx, Rect{X: 0, Y: 0, W: 1, H: 1}, ctx.Config.FocusColor, 0)
+		if err != nil {
+			p.Destroy()
+			return nil, err
+		}
+		p.Sides[i] = d
+	}
+	return p, nil
+}
+
+// Show outlines a shape above everything else.
+func (p *DropPreview) Show(ctx *Context, r Rect) {
+	t := ext.IMax(ctx.Config.ElemSize/2, 1)
+	shapes := [4]Rect{
+		{X: r.X, Y: r.Y, W: r.W, H: t},
+		{X: r.X, Y: r.Y + r.H - t, W: r.W, H: t},
+		{X: r.X, Y: r.Y, W: t, H: r.H},
+		{X: r.X + r.W - t, Y: r.Y, W: t, H: r.H},
+	}
+	for i := range p.Sides {
+		p.Sides[i].MoveResize(shapes[i])
+		p.Sides[i].Window.Map()
+		p.Sides[i].Window.Stack(xproto.StackModeAbove)
+	}
+}
+
+func (p *DropPreview) Hide() {
+	for _, d := range p.Sides {
+		if d.Window != nil {
+			d.Window.Unmap()
+		}
+	}
+}
+
+func (p *DropPreview) Destroy() {
+	for _, d := range p.Sides {
+		if d.Window != nil {
+			d.Window.Destroy()
+		}
+	}
+}
+
+// ContainerAt returns the topmost shown container under a point, nil if there is none.
+// Floating containers are passed over unless floating is set.
+func ContainerAt(ctx *Context, x, y int, floating bool) *Container {
+	tree, err := xproto.QueryTree(ctx.X.Conn(), ctx.X.RootWin()).Reply()
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	// Children are listed from the bottom of the stack to the top
+	for i := len(tree.Children) - 1; i >= 0; i-- {
+		f := ctx.Get(tree.Children[i])
+		if f == nil || f.IsOrphan() || !f.Mapped || !f.Container.Shown(ctx) || (f.Container.Floating && !floating) {
+			continue
+		}
+		if f.Container.Shape.Contains(x, y) {
+			return f.Container
+		}
+	}
+	return nil
+}
+
+// onDesktop checks if a point is on the bare desktop, inside the work area of a screen and not over a dock.
+func onDesktop(ctx *Context, x, y int) bool {
+	inWorkArea := false
+	for _, s := range ctx.Screens {
+		if area := ctx.WorkArea(s); area.Contains(x, y) {
+			inWorkArea = true
+		}
+	}
+	if !inWorkArea {
+		return false
+	}
+	// Docks that don't reserve space can still be in the work area
+	for _, d := range ctx.Docks {
+		if !d.Mapped {
+			continue
+		}
+		geom, err := d.Window.Geometry()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		shape := Rect{X: geom.X(), Y: geom.Y(), W: geom.Width(), H: geom.Height()}
+		if shape.Contains(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// DropTarget finds the leaf under a point that a frame would be dropped onto, and the side of it the frame
+// would land on. Only the topmost tiled container under the point counts. If the point is on the bare desktop,
+// desktop is set.
+func DropTarget(ctx *Context, source *Frame, x, y int) (target *Frame, side AnchorType, desktop bool) {
+	c := ContainerAt(ctx, x, y, false)
+	if c == nil {
+		return nil, NONE, onDesktop(ctx, x, y)
+	}
+
+	target = c.ActiveRoot().Find(func(f *Frame) bool {
+		return f.IsLeaf() && f.Mapped && f.Shape.Contains(x, y)
+	})
+	if target == nil || target == source {
+		return nil, NONE, false
+	}
+
+	// Whichever edge the point is closest to, relative to the size of the frame
+	s := target.Shape
+	dx := float64(x-s.X)/float64(s.W) - .5
+	dy := float64(y-s.Y)/float64(s.H) - .5
+	switch {
+	case dx*dx >= dy*dy && dx < 0:
+		side = LEFT
+	case dx*dx >= dy*dy:
+		side = RIGHT
+	case dy < 0:
+		side = TOP
+	default:
+		side = BOTTOM
+	}
+	return target, side, false
+}
+
+// DropShape is the half of a frame that a frame dropped on the given side of it would take.
+func DropShape(target *Frame, side AnchorType) Rect {
+	s := target.Shape
+	switch side {
+	case LEFT:
+		s.W /= 2
+	case RIGHT:
+		s.X += s.W / 2
+		s.W -= s.W / 2
+	case TOP:
+		s.H /= 2
+	case BOTTOM:
+		s.Y += s.H / 2
+		s.H -= s.H / 2
+	}
+	return s
+}
+
+// DropFrame moves a leaf next to another one, on the given side of it.
+func (f *Frame) DropFrame(ctx *Context, target *Frame, side AnchorType) {
+	if f == target || f.IsOrphan() || target.IsOrphan() || !f.IsLeaf() || !target.IsLeaf() {
+		return
+	}
+	ctx.RecordLayout()
+	f.Orphan(ctx)
+
+	partition := HORIZONTAL
+	if side == TOP || side == BOTTOM {
+		partition = VERTICAL
+	}
+	target.Insert(ctx, partition, f, side == LEFT || side == TOP, true)
+	f.Map()
+	f.Parent.MoveResize(ctx)
+	f.Container.Raise(ctx)
+	f.Focus(ctx)
+	ctx.Taskbar.UpdateContainer(ctx, f.Container)
+}
+
+// AddDropHook lets a window be dragged by its frame onto another frame, or onto the desktop to pop it out.
+func AddDropHook(ctx *Context, window xproto.Window) {
+	var preview *DropPreview
+	mousebind.Drag(
+		ctx.X, window, window, ctx.Config.ButtonDragFrame, true,
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			f := ctx.Get(window)
+			if ctx.Locked || f == nil || f.IsOrphan() || f.Container.Floating {
+				return false, 0
+			}
+			var err error
+			preview, err = NewDropPreview(ctx)
+			if err != nil {
+				log.Println(err)
+				return false, 0
+			}
+			return true, ctx.Cursors[xcursor.Fleur]
+		},
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
+			target, side, _ := DropTarget(ctx, ctx.Get(window), rX, rY)
+			if target == nil {
+				preview.Hide()
+				return
+			}
+			preview.Show(ctx, DropShape(target, side))
+		},
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
+			preview.Destroy()
+			preview = nil
+
+			f := ctx.Get(window)
+			if f == nil || f.IsOrphan() {
+				return
+			}
+			target, side, desktop := DropTarget(ctx, f, rX, rY)
+			if desktop {
+				// Whatever gets dropped on the desktop ends up centered where it was dropped
+				ctx.RecordLayout()
+				f.Pop(ctx)
+				s := f.Container.Shape
+				f.Container.MoveResize(ctx, rX-s.W/2, rY-s.H/2, s.W, s.H)
+			} else if target != nil {
+				f.DropFrame(ctx, target, side)
+			}
+		},
+	)
+}
@@ -0,0 +1,55 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/icccm"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind by before it is dropped.
+const subscriberBuffer = 256
+
+// Subscribe returns a channel that every event is sent to until Unsubscribe is called.
+// If the subscriber falls too far behind the channel is closed instead of holding up the X loop.
+func (ctx *Context) Subscribe() chan ipc.Event {
+	ch := make(chan ipc.Event, subscriberBuffer)
+	ctx.Subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (ctx *Context) Unsubscribe(ch chan ipc.Event) {
+	if _, ok := ctx.Subscribers[ch]; ok {
+		delete(ctx.Subscribers, ch)
+		close(ch)
+	}
+}
+
+// Emit sends an event to every subscriber.
+func (ctx *Context) Emit(ev ipc.Event) {
+	ev.Time = time.Now()
+	for ch := range ctx.Subscribers {
+		select {
+		case ch <- ev:
+		default:
+			ctx.Unsubscribe(ch)
+		}
+	}
+}
+
+// EmitWindow sends an event about a window, looking up its class and title for subscribers.
+func (ctx *Context) EmitWindow(eventType string, window xproto.Window) {
+	if len(ctx.Subscribers) == 0 {
+		return
+	}
+
+	ev := ipc.Event{Type: eventType, Window: uint32(window)}
+	if eventType != ipc.EventDestroy {
+		if class, err := icccm.WmClassGet(ctx.X, window); err == nil {
+			ev.Class = class.Class
+		}
+		ev.Title = ext.WindowTitle(ctx.X, window)
+	}
+	ctx.Emit(ev)
+}
@@ -0,0 +1,137 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+	"sort"
+)
+
+// SupportedHints are the EWMH hints we implement, published in _NET_SUPPORTED.
+var SupportedHints = []string{
+	"_NET_SUPPORTED",
+	"_NET_SUPPORTING_WM_CHECK",
+	"_NET_CLIENT_LIST",
+	"_NET_CLIENT_LIST_STACKING",
+	"_NET_ACTIVE_WINDOW",
+	"_NET_CLOSE_WINDOW",
+	"_NET_WM_NAME",
+}
+
+// SetupEwmh announces that an EWMH compliant window manager is running and which hints it supports.
+func SetupEwmh(ctx *Context) error {
+	// Clients find the window manager through a child window that points to itself
+	check, err := xwindow.Generate(ctx.X)
+	if err != nil {
+		return err
+	}
+	err = check.CreateChecked(ctx.X.RootWin(), -1, -1, 1, 1, xproto.CwOverrideRedirect, 1)
+	if err != nil {
+		return err
+	}
+	for _, w := range []xproto.Window{ctx.X.RootWin(), check.Id} {
+		if err := ewmh.SupportingWmCheckSet(ctx.X, w, check.Id); err != nil {
+			return err
+		}
+	}
+	if err := ewmh.WmNameSet(ctx.X, check.Id, "rowm"); err != nil {
+		return err
+	}
+
+	if err := ewmh.SupportedSet(ctx.X, SupportedHints); err != nil {
+		return err
+	}
+	// Clear out lists left behind by an earlier window manager, they are only published again on changes
+	ext.Logerr(ewmh.ClientListSet(ctx.X, nil))
+	ext.Logerr(ewmh.ClientListStackingSet(ctx.X, nil))
+	ctx.SetActiveWindow(0)
+	ctx.UpdateWorkspaceHints()
+	ctx.UpdateWorkArea()
+	return nil
+}
+
+// UpdateClientList marks the client lists as changed, they are published once the current event is handled.
+func (ctx *Context) UpdateClientList() {
+	ctx.ClientListStale = true
+}
+
+// FlushClientList publishes every managed window in _NET_CLIENT_LIST, oldest first,
+// and in _NET_CLIENT_LIST_STACKING from bottom to top, if anything changed since the last time.
+func (ctx *Context) FlushClientList() {
+	if !ctx.ClientListStale {
+		return
+	}
+	ctx.ClientListStale = false
+
+	managed := func(w xproto.Window) bool {
+		f := ctx.Get(w)
+		return f != nil && !f.IsOrphan() && f.IsLeaf()
+	}
+
+	before := len(ctx.Clients)
+	clients := ctx.Clients[:0]
+	listed := make(map[xproto.Window]bool)
+	for _, w := range ctx.Clients {
+		if managed(w) {
+			clients = append(clients, w)
+			listed[w] = true
+		}
+	}
+	added := make([]xproto.Window, 0)
+	for w := range ctx.Tracked {
+		if !listed[w] && managed(w) {
+			added = append(added, w)
+		}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+	ctx.Clients = append(clients, added...)
+	if len(added) > 0 || len(clients) != before {
+		ext.Logerr(ewmh.ClientListSet(ctx.X, ctx.Clients))
+	}
+
+	current := make(map[xproto.Window]bool)
+	for _, w := range ctx.Clients {
+		current[w] = true
+		ctx.updateWmDesktop(w, ctx.Get(w).Container.Workspace)
+	}
+	for w := range ctx.WmDesktops {
+		if !current[w] {
+			delete(ctx.WmDesktops, w)
+		}
+	}
+
+	// Clients are never reparented, so the root's children are already in stacking order
+	tree, err := xproto.QueryTree(ctx.X.Conn(), ctx.X.RootWin()).Reply()
+	if err != nil {
+		ext.Logerr(err)
+		return
+	}
+	stacking := make([]xproto.Window, 0, len(ctx.Clients))
+	for _, w := range tree.Children {
+		if managed(w) {
+			stacking = append(stacking, w)
+		}
+	}
+	if !sameWindows(stacking, ctx.Stacking) {
+		ctx.Stacking = stacking
+		ext.Logerr(ewmh.ClientListStackingSet(ctx.X, stacking))
+	}
+}
+
+func sameWindows(a, b []xproto.Window) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetActiveWindow publishes the focused window in _NET_ACTIVE_WINDOW, 0 if no window is focused.
+func (ctx *Context) SetActiveWindow(w xproto.Window) {
+	ext.Logerr(ewmh.ActiveWindowSet(ctx.X, w))
+}
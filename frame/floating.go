@@ -0,0 +1,80 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/icccm"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+)
+
+// floatingTypes are the _NET_WM_WINDOW_TYPE values that float instead of being tiled,
+// mapped to whether they should take focus when they show up.
+var floatingTypes = map[string]bool{
+	"_NET_WM_WINDOW_TYPE_DIALOG":        true,
+	"_NET_WM_WINDOW_TYPE_UTILITY":       true,
+	"_NET_WM_WINDOW_TYPE_NOTIFICATION":  false,
+	"_NET_WM_WINDOW_TYPE_SPLASH":        false,
+	"_NET_WM_WINDOW_TYPE_TOOLTIP":       false,
+	"_NET_WM_WINDOW_TYPE_POPUP_MENU":    false,
+	"_NET_WM_WINDOW_TYPE_DROPDOWN_MENU": false,
+	"_NET_WM_WINDOW_TYPE_COMBO":         false,
+	"_NET_WM_WINDOW_TYPE_DND":           false,
+}
+
+func init() {
+	SupportedHints = append(SupportedHints, "_NET_WM_WINDOW_TYPE")
+	for t := range floatingTypes {
+		SupportedHints = append(SupportedHints, t)
+	}
+}
+
+// ShouldFloat decides whether a window floats instead of being tiled, which is the case for
+// dialog-like window types and for windows that are transient for another window.
+// It also returns whether the window should be focused, and the container it belongs over (if any).
+func ShouldFloat(ctx *Context, window xproto.Window) (bool, bool, *Container) {
+	var parent *Container
+	transient := false
+	if parentWin, err := icccm.WmTransientForGet(ctx.X, window); err == nil && parentWin != 0 && parentWin != window {
+		transient = true
+		if pf := ctx.Get(parentWin); pf != nil && !pf.IsOrphan() {
+			parent = pf.Container
+		}
+	}
+
+	types, _ := ewmh.WmWindowTypeGet(ctx.X, window)
+	for _, t := range types {
+		if focus, ok := floatingTypes[t]; ok {
+			return true, focus, parent
+		}
+		if t == "_NET_WM_WINDOW_TYPE_NORMAL" {
+			break
+		}
+	}
+	return transient, true, parent
+}
+
+// NewFloatingWindow puts a window in an undecorated container of its own size, centered over its parent.
+func NewFloatingWindow(ctx *Context, window xproto.Window, existing *Frame, parent *Container, focus bool) *Frame {
+	area := ctx.LastFocusedScreen()
+	if parent != nil && !parent.Hidden {
+		area = parent.Shape
+	}
+	screen, _, _ := ctx.GetScreenForShape(area)
+
+	shape := ctx.DefaultShapeForScreen(screen)
+	if geom, err := xwindow.New(ctx.X, window).Geometry(); err == nil {
+		shape.W = ext.IMin(geom.Width(), screen.W)
+		shape.H = ext.IMin(geom.Height(), screen.H)
+	}
+	shape.X = ext.IClamp(area.X+(area.W-shape.W)/2, screen.X, screen.X+screen.W-shape.W)
+	shape.Y = ext.IClamp(area.Y+(area.H-shape.H)/2, screen.Y, screen.Y+screen.H-shape.H)
+
+	c := &Container{
+		Shape:        shape,
+		Floating:     true,
+		TransientFor: parent,
+	}
+	c.Decorations.Hidden = true
+	return WrapWindow(ctx, c, window, existing, focus)
+}
@@ -4,6 +4,7 @@ import (
 	"container/list"
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/icccm"
 	"github.com/BurntSushi/xgbutil/keybind"
 	"github.com/BurntSushi/xgbutil/mousebind"
 	"github.com/BurntSushi/xgbutil/xcursor"
@@ -11,29 +12,32 @@ import (
 	"github.com/BurntSushi/xgbutil/xprop"
 	"github.com/BurntSushi/xgbutil/xwindow"
 	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
 	"log"
+	"math"
 )
 
 // Frame represents a node in the tree like structure of panels in each container.
 // A frame can either be a leaf node, which has a user created window to display,
-// or it can display a seperator decoration that allows the resizing of its child frames.
+// or it can display seperator decorations that allow the resizing of its child frames.
 type Frame struct {
-	Shape                  Rect
-	Window                 *xwindow.Window
-	Container              *Container
-	Parent, ChildA, ChildB *Frame
-	Separator              Partition
-	Mapped                 bool
+	Shape     Rect
+	Window    *xwindow.Window
+	Container *Container
+	Parent    *Frame
+	Children  []*Frame // In order from left to right or top to bottom, leaves have none
+	Separator Partition
+	Mapped    bool
+	Hints     *SizeHints // Size constraints of the client, loaded when first needed
+	Urgent    bool       // The client wants attention, until it is focused
+	TabActive bool       // The tab shown by its tab group
 }
 
 // Traverse will visit every frame in the tree starting at the input frame.
 func (f *Frame) Traverse(fun func(*Frame)) {
 	fun(f)
-	if f.ChildA != nil {
-		f.ChildA.Traverse(fun)
-	}
-	if f.ChildB != nil {
-		f.ChildB.Traverse(fun)
+	for _, child := range f.Children {
+		child.Traverse(fun)
 	}
 }
 
@@ -43,12 +47,10 @@ func (f *Frame) Find(fun func(*Frame) bool) *Frame {
 		return f
 	}
 
-	if fA := f.ChildA.Find(fun); fA != nil {
-		return fA
-	}
-
-	if fB := f.ChildB.Find(fun); fB != nil {
-		return fB
+	for _, child := range f.Children {
+		if found := child.Find(fun); found != nil {
+			return found
+		}
 	}
 
 	return nil
@@ -77,12 +79,8 @@ func (f *Frame) FindNearest(fun func(*Frame) bool) *Frame {
 			return fr
 		}
 
-		if fr.ChildA != nil {
-			pb(fr.ChildA)
-		}
-
-		if fr.ChildB != nil {
-			pb(fr.ChildB)
+		for _, child := range fr.Children {
+			pb(child)
 		}
 
 		if fr.Parent != nil {
@@ -95,8 +93,8 @@ func (f *Frame) FindNearest(fun func(*Frame) bool) *Frame {
 // Returns the first node in a subtree, root must be a parent of f or nil
 func (f *Frame) GetLeftmostFrameInSubtree() *Frame {
 	curr := f
-	for curr.ChildA != nil {
-		curr = curr.ChildA
+	for !curr.IsLeaf() {
+		curr = curr.Children[0]
 	}
 	return curr
 }
@@ -104,8 +102,8 @@ func (f *Frame) GetLeftmostFrameInSubtree() *Frame {
 // Returns the last node in a subtree, root must be a parent of f or nil
 func (f *Frame) GetRightmostFrameInSubtree() *Frame {
 	curr := f
-	for curr.ChildB != nil {
-		curr = curr.ChildB
+	for !curr.IsLeaf() {
+		curr = curr.Children[len(curr.Children)-1]
 	}
 	return curr
 }
@@ -157,10 +155,11 @@ func (f *Frame) FindNextLeaf(fun func(*Frame) bool, reversed bool, root *Frame)
 			if fc == nil || (fc == root && !fc.IsLeaf()) {
 				return nil
 			}
-			if !reversed && fc == fc.Parent.ChildA {
-				return fc.Parent.ChildB.GetLeftmostFrameInSubtree()
-			} else if reversed && fc == fc.Parent.ChildB {
-				return fc.Parent.ChildA.GetRightmostFrameInSubtree()
+			siblings, i := fc.Parent.Children, fc.Index()
+			if !reversed && i < len(siblings)-1 {
+				return siblings[i+1].GetLeftmostFrameInSubtree()
+			} else if reversed && i > 0 {
+				return siblings[i-1].GetRightmostFrameInSubtree()
 			}
 			fc = fc.Parent
 		}
@@ -199,7 +198,7 @@ func (f *Frame) Root() *Frame {
 func (f *Frame) Map() {
 	f.Traverse(
 		func(ft *Frame) {
-			if ft.Mapped {
+			if ft.Mapped || ft.TabHidden() {
 				return
 			}
 
@@ -207,8 +206,8 @@ func (f *Frame) Map() {
 				ft.Window.Map()
 			}
 
-			if ft.Separator.Decoration.Window != nil {
-				ft.Separator.Decoration.Window.Map()
+			for _, d := range ft.Separator.Decorations {
+				d.Window.Map()
 			}
 			ft.Mapped = true
 		},
@@ -226,8 +225,8 @@ func (f *Frame) UnmapSingle(ctx *Context) {
 		// every time we unmap something internally, but we only care about external ones.
 		ctx.UnmapCounter[f.Window.Id]++
 	}
-	if f.Separator.Decoration.Window != nil {
-		f.Separator.Decoration.Window.Unmap()
+	for _, d := range f.Separator.Decorations {
+		d.Window.Unmap()
 	}
 
 	f.Mapped = false
@@ -267,8 +266,17 @@ func (f *Frame) Close(ctx *Context) {
 	})
 }
 
+// Pop moves a leaf frame out of its split and into a container of its own.
+func (f *Frame) Pop(ctx *Context) {
+	if f.IsLeaf() && !f.IsRoot() {
+		ctx.RecordLayout()
+		f.Orphan(ctx)
+		NewContainer(ctx, f.Window.Id, f)
+	}
+}
+
 func (f *Frame) IsLeaf() bool {
-	return f.ChildA == nil && f.ChildB == nil
+	return len(f.Children) == 0
 }
 
 func (f *Frame) IsRoot() bool {
@@ -280,6 +288,7 @@ func (f *Frame) IsOrphan() bool {
 }
 
 // Orphan removes a frame from its container and reorganizes the tree to fill the gap.
+// Its siblings share the space it had.
 func (f *Frame) Orphan(ctx *Context) {
 	if f.Container == nil {
 		log.Println("orphan called on already orphaned frame")
@@ -290,67 +299,205 @@ func (f *Frame) Orphan(ctx *Context) {
 		return
 	}
 	f.UnmapSingle(ctx)
+	c := f.Container
 	defer func() {
 		f.Parent = nil
 		f.Container = nil
+		f.TabActive = false
 	}()
 
 	if f.IsRoot() {
-		f.Container.Destroy(ctx)
+		c.Destroy(ctx)
 		return
 	}
 
-	if f.Container.Expanded == f {
-		f.Container.Expanded = nil
-		f.Container.UpdateFrameMappings(ctx)
+	if c.Expanded == f {
+		c.Expanded = nil
+		c.UpdateFrameMappings(ctx)
+	}
+
+	par := f.Parent
+	wasMapped := par.Mapped
+	i := f.Index()
+	par.RemoveChild(ctx, i)
+	// The tab next to a closed one is shown in its place
+	if f.TabActive && par.IsTabbed() {
+		par.Children[ext.IMin(i, len(par.Children)-1)].TabActive = true
+	}
+
+	moved := par
+	if len(par.Children) == 1 {
+		moved = par.Collapse(ctx)
 	}
+	c.UpdateTabs(ctx)
+	if moved.Mapped || wasMapped {
+		moved.MoveResize(ctx)
+	}
+	ctx.Taskbar.UpdateContainer(ctx, c)
+}
 
-	oc := func() *Frame {
-		if f.Parent.ChildA == f {
-			return f.Parent.ChildB
+// Index returns the position of a frame among its siblings, -1 if it has no parent.
+func (f *Frame) Index() int {
+	if f.Parent == nil {
+		return -1
+	}
+	for i, child := range f.Parent.Children {
+		if child == f {
+			return i
+		}
+	}
+	return -1
+}
+
+// Share returns the part of its parent's space a frame is given.
+func (f *Frame) Share() float64 {
+	i := f.Index()
+	if i < 0 {
+		return 1
+	}
+	return f.Parent.weights()[i]
+}
+
+// weights returns the share of every child of a split, giving each one the same share
+// if the weights don't match up with the children.
+func (f *Frame) weights() []float64 {
+	n := len(f.Children)
+	weights := make([]float64, n)
+	sum := 0.0
+	if len(f.Separator.Weights) == n {
+		for _, w := range f.Separator.Weights {
+			sum += math.Max(w, 0)
+		}
+	}
+	for i := range weights {
+		if sum > 0 {
+			weights[i] = math.Max(f.Separator.Weights[i], 0) / sum
 		} else {
-			return f.Parent.ChildA
+			weights[i] = 1 / float64(n)
 		}
-	}()
+	}
+	return weights
+}
+
+// NormalizeWeights makes the weights of a split add up to 1.
+func (f *Frame) NormalizeWeights() {
+	f.Separator.Weights = f.weights()
+}
+
+// RemoveChild takes a child out of a split, the other children share the space it had.
+func (f *Frame) RemoveChild(ctx *Context, i int) {
+	f.Separator.Weights = dropWeight(f.weights(), i)
+	f.Children = append(f.Children[:i], f.Children[i+1:]...)
+	f.UpdateDecorations(ctx)
+}
 
-	par := oc.Parent
-	oc.Parent = par.Parent
-	if oc.Parent != nil {
-		if oc.Parent.ChildA == par {
-			oc.Parent.ChildA = oc
+// dropWeight takes weight i out, scaling up the others so they add up to what they did before.
+func dropWeight(weights []float64, i int) []float64 {
+	sum, rest := 0.0, 0.0
+	for j, w := range weights {
+		sum += w
+		if j != i {
+			rest += w
 		}
-		if oc.Parent.ChildB == par {
-			oc.Parent.ChildB = oc
+	}
+	weights = append(weights[:i], weights[i+1:]...)
+	for j := range weights {
+		if rest > 0 {
+			weights[j] *= sum / rest
+		} else {
+			weights[j] = sum / float64(len(weights))
+		}
+	}
+	return weights
+}
+
+// Splice replaces child i of a split with the children of a frame that splits the same way,
+// each getting their part of the share it had. The frame they came from is destroyed.
+func (f *Frame) Splice(ctx *Context, i int, node *Frame) {
+	children := make([]*Frame, 0, len(f.Children)+len(node.Children)-1)
+	children = append(children, f.Children[:i]...)
+	for _, child := range node.Children {
+		child.Parent = f
+		// Only the tabs of a group that was being shown can stay active
+		if !node.TabActive {
+			child.TabActive = false
 		}
+		children = append(children, child)
+	}
+	children = append(children, f.Children[i+1:]...)
+	weights := spliceWeight(f.weights(), i, node.weights())
+
+	if c := f.Container; c != nil && c.Expanded == node {
+		c.Expanded = nil
+	}
+	node.Isolate(ctx)
+	node.Destroy(ctx)
+
+	f.Children = children
+	f.Separator.Weights = weights
+	f.UpdateDecorations(ctx)
+}
+
+// spliceWeight replaces weight i with the given weights, scaled down to share what weight i had.
+func spliceWeight(weights []float64, i int, inner []float64) []float64 {
+	spliced := make([]float64, 0, len(weights)+len(inner)-1)
+	spliced = append(spliced, weights[:i]...)
+	for _, w := range inner {
+		spliced = append(spliced, weights[i]*w)
 	}
-	if par.IsRoot() {
-		oc.Container.Root = oc
+	return append(spliced, weights[i+1:]...)
+}
+
+// Collapse replaces a split that has a single child left with that child, and returns the frame whose
+// shape needs to be recalculated. If the child splits the same way as the split above, its children
+// join that split instead.
+func (f *Frame) Collapse(ctx *Context) *Frame {
+	c := f.Container
+	oc := f.Children[0]
+	gp := f.Parent
+	i := f.Index()
+	oc.Parent = gp
+	if f.TabActive {
+		oc.TabActive = true
 	}
-	par.Isolate(ctx)
-	par.Destroy(ctx)
-	if oc.Mapped {
-		oc.MoveResize(ctx)
+	if c.Expanded == f {
+		c.Expanded = oc
 	}
-	ctx.Taskbar.UpdateContainer(ctx, f.Container)
+
+	moved := oc
+	if gp == nil {
+		c.Root = oc
+	} else if !oc.IsLeaf() && oc.Separator.Type == gp.Separator.Type && c.Expanded != oc {
+		gp.Splice(ctx, i, oc)
+		moved = gp
+	} else {
+		gp.Children[i] = oc
+	}
+
+	f.Isolate(ctx)
+	f.Destroy(ctx)
+	return moved
 }
 
 func (f *Frame) Isolate(ctx *Context) {
 	f.Parent = nil
-	f.ChildA = nil
-	f.ChildB = nil
+	f.Children = nil
 	f.Container = nil
 }
 
 func (f *Frame) Destroy(ctx *Context) {
 	f.UnmapSingle(ctx)
-	f.Orphan(ctx)
+	if !f.IsOrphan() {
+		f.Orphan(ctx)
+	}
 	if f.Window != nil {
 		f.Window.Destroy()
 		delete(ctx.Tracked, f.Window.Id)
 	}
-	if f.Separator.Decoration.Window != nil {
-		f.Separator.Decoration.Window.Destroy()
+	for _, d := range f.Separator.Decorations {
+		d.Window.Destroy()
 	}
+	f.Separator.Decorations = nil
 }
 
 func (f *Frame) Raise(ctx *Context) {
@@ -360,8 +507,8 @@ func (f *Frame) Raise(ctx *Context) {
 }
 
 func (f *Frame) RaiseDecoration(ctx *Context) {
-	if f.Separator.Decoration.Window != nil {
-		f.Separator.Decoration.Window.Stack(xproto.StackModeAbove)
+	for _, d := range f.Separator.Decorations {
+		d.Window.Stack(xproto.StackModeAbove)
 	}
 }
 
@@ -370,12 +517,36 @@ func (f *Frame) Focus(ctx *Context) {
 		return ff.IsLeaf()
 	})
 	if leaf != nil {
+		leaf.RevealTabs(ctx)
 		ext.Focus(leaf.Window)
+		leaf.SetUrgent(ctx, false)
+		if ctx.LastKnownFocused != leaf.Window.Id {
+			ctx.EmitWindow(ipc.EventFocus, leaf.Window.Id)
+		}
 		ctx.LastKnownFocused = leaf.Window.Id
-		_, _, ctx.LastKnownFocusedScreen = ctx.GetScreenForShape(leaf.Container.Shape)
+		ctx.SetActiveWindow(leaf.Window.Id)
+		if screen := ctx.ScreenIndex(leaf.Container); screen != ctx.LastKnownFocusedScreen {
+			ctx.LastKnownFocusedScreen = screen
+			ctx.UpdateWorkspaceHints()
+		}
 	}
 }
 
+// SetUrgent marks whether a window wants attention, which is shown on the taskbar until it gets focused.
+func (f *Frame) SetUrgent(ctx *Context, urgent bool) {
+	if urgent && ctx.LastKnownFocused == f.Window.Id {
+		urgent = false
+	}
+	if f.Urgent == urgent {
+		return
+	}
+	f.Urgent = urgent
+	if !f.IsOrphan() {
+		f.Container.UpdateWmState(ctx)
+	}
+	ctx.Taskbar.UpdateWorkspaces(ctx)
+}
+
 func (f *Frame) FocusRaise(ctx *Context) {
 	if f.IsOrphan() {
 		log.Println("tried to raise an orphan")
@@ -393,12 +564,12 @@ func (f *Frame) MoveResize(ctx *Context) {
 	}
 	f.Traverse(func(ft *Frame) {
 		ft.Shape = ft.CalcShape(ctx)
-		if ft.Shape.W == 0 || ft.Shape.H == 0 {
+		if ft.Shape.W == 0 || ft.Shape.H == 0 || ft.TabHidden() {
 			if ft.Mapped {
 				ft.Unmap(ctx)
 			}
 		} else {
-			if !ft.Mapped {
+			if !ft.Mapped && ft.Container.Shown(ctx) {
 				ft.Map()
 			}
 		}
@@ -406,45 +577,88 @@ func (f *Frame) MoveResize(ctx *Context) {
 		if ft.IsLeaf() {
 			ft.Window.MoveResize(ft.Shape.X, ft.Shape.Y, ft.Shape.W, ft.Shape.H)
 		}
-		if ft.Separator.Decoration.Window != nil {
-			ft.Separator.Decoration.MoveResize(ft.SeparatorShape(ctx))
+		for i, d := range ft.Separator.Decorations {
+			d.MoveResize(ft.SeparatorShape(ctx, i))
+		}
+		if ft.IsTabbed() {
+			ft.DrawTabStrip(ctx)
 		}
 	})
 }
 
-func (f *Frame) CreateSeparatorDecoration(ctx *Context) {
-	s := f.SeparatorShape(ctx)
-	cursor := ctx.Cursors[xcursor.SBHDoubleArrow]
-	if f.Separator.Type == VERTICAL {
-		cursor = ctx.Cursors[xcursor.SBVDoubleArrow]
+// UpdateDecorations creates or removes decorations so that a split has a separator between each pair
+// of its children, and a tab group has its tab strip.
+func (f *Frame) UpdateDecorations(ctx *Context) {
+	want := 0
+	if f.IsTabbed() {
+		want = 1
+	} else if !f.IsLeaf() {
+		want = len(f.Children) - 1
 	}
 
-	var err error
-	f.Separator.Decoration, err = CreateDecoration(
-		ctx, s, ctx.Config.SeparatorColor, uint32(cursor))
+	for len(f.Separator.Decorations) > want {
+		last := len(f.Separator.Decorations) - 1
+		f.Separator.Decorations[last].Window.Destroy()
+		f.Separator.Decorations = f.Separator.Decorations[:last]
+	}
+	for len(f.Separator.Decorations) < want {
+		var d Decoration
+		var err error
+		if f.IsTabbed() {
+			d, err = f.CreateTabStripDecoration(ctx)
+		} else {
+			d, err = f.CreateSeparatorDecoration(ctx)
+		}
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if s := f.SeparatorShape(ctx, len(f.Separator.Decorations)); s.W > 0 && s.H > 0 {
+			d.MoveResize(s)
+		}
+		f.Separator.Decorations = append(f.Separator.Decorations, d)
+		if f.Mapped {
+			d.Window.Map()
+		}
+	}
+}
 
-	if err != nil {
-		log.Println(err)
-		return
+// decorationIndex returns which of a frame's decorations a window is, -1 if it is none of them.
+func (f *Frame) decorationIndex(w *xwindow.Window) int {
+	for i, d := range f.Separator.Decorations {
+		if d.Window == w {
+			return i
+		}
 	}
+	return -1
+}
 
-	f.Separator.Decoration.MoveResize(s)
-	if err := ext.MapChecked(f.Separator.Decoration.Window); err != nil {
-		log.Println("CreateSeparatorDecoration:", f.Separator.Decoration.Window, "could not be mapped", err, s)
+// CreateSeparatorDecoration creates a separator of a split, dragging it trades space between the
+// children on either side of it.
+func (f *Frame) CreateSeparatorDecoration(ctx *Context) (Decoration, error) {
+	d, err := CreateDecoration(ctx, Rect{X: 0, Y: 0, W: 1, H: 1}, ctx.Config.SeparatorColor, uint32(f.SeparatorCursor(ctx)))
+	if err != nil {
+		return d, err
 	}
 
 	mousebind.Drag(
-		ctx.X, f.Separator.Decoration.Window.Id, f.Separator.Decoration.Window.Id, ctx.Config.ButtonDrag, true,
+		ctx.X, d.Window.Id, d.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			f.Container.DragContext = GenerateDragContext(ctx, f.Container, f, rX, rY)
 			f.Container.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
+			// Separators come and go as children do, so look up which one this is
+			i := f.decorationIndex(d.Window)
+			if i < 0 {
+				return
+			}
 			if f.Separator.Type == HORIZONTAL {
-				f.Separator.Ratio = ext.Clamp((float64(rX)-float64(f.Shape.X))/float64(f.Shape.W), 0, 1)
+				f.MoveSeparator(ctx, i, rX-f.Shape.X)
 			} else {
-				f.Separator.Ratio = ext.Clamp((float64(rY)-float64(f.Shape.Y))/float64(f.Shape.H), 0, 1)
+				f.MoveSeparator(ctx, i, rY-f.Shape.Y)
 			}
 			f.MoveResize(ctx)
 		},
@@ -452,10 +666,21 @@ func (f *Frame) CreateSeparatorDecoration(ctx *Context) {
 			f.Container.RaiseFindFocus(ctx)
 		},
 	)
+	return d, nil
 }
 
-// CalcShape returns the shape a frame should be based off of its container and parent
+// CalcShape returns the shape a frame should be based off of its container and parent,
+// shrunk to fit the size hints of its window if it is a leaf
 func (f *Frame) CalcShape(ctx *Context) Rect {
+	shape := f.calcSlot(ctx)
+	if f.IsLeaf() && !f.Container.WmState.Fullscreen {
+		return f.SizeHints(ctx).Constrain(shape)
+	}
+	return shape
+}
+
+// calcSlot returns the space a frame is given in its container
+func (f *Frame) calcSlot(ctx *Context) Rect {
 	if f == f.Container.ActiveRoot() {
 		return RootShape(ctx, f.Container)
 	}
@@ -468,60 +693,60 @@ func (f *Frame) CalcShape(ctx *Context) Rect {
 		}
 	}()
 
-	isChildA := (f.Parent.ChildA == f)
+	return f.Parent.ChildSlots(ctx, pShape)[f.Index()]
+}
 
-	WidthA := func() int {
-		return ext.IMax(int(float64(pShape.W)*f.Parent.Separator.Ratio), ctx.Config.ElemSize) - ctx.Config.ElemSize
+// alongSplit returns the position and size of a shape in the direction a split lays out its children.
+func (f *Frame) alongSplit(r Rect) (int, int) {
+	if f.Separator.Type == VERTICAL {
+		return r.Y, r.H
 	}
-	HeightA := func() int {
-		return ext.IMax(int(float64(pShape.H)*f.Parent.Separator.Ratio), ctx.Config.ElemSize) - ctx.Config.ElemSize
+	return r.X, r.W
+}
+
+// ChildSlots divides the shape of a split between its children by their weights,
+// leaving room for the separators between them.
+func (f *Frame) ChildSlots(ctx *Context, shape Rect) []Rect {
+	n := len(f.Children)
+	slots := make([]Rect, n)
+	if f.IsTabbed() {
+		content := f.TabContentShape(ctx, shape)
+		for i := range slots {
+			slots[i] = content
+		}
+		return slots
 	}
 
-	if isChildA {
-		if f.Parent.Separator.Type == HORIZONTAL {
-			return Rect{
-				X: pShape.X,
-				Y: pShape.Y,
-				W: WidthA(),
-				H: pShape.H,
-			}
-		} else {
-			return Rect{
-				X: pShape.X,
-				Y: pShape.Y,
-				W: pShape.W,
-				H: HeightA(),
-			}
+	_, total := f.alongSplit(shape)
+	avail := ext.IMax(total-(n-1)*ctx.Config.ElemSize, 0)
+	acc := 0.0
+	prev := 0
+	for i, w := range f.weights() {
+		acc += w
+		end := avail
+		if i < n-1 {
+			end = ext.IClamp(int(float64(avail)*acc), prev, avail)
 		}
-	} else {
-		if f.Parent.Separator.Type == HORIZONTAL {
-			return Rect{
-				X: pShape.X + WidthA() + ctx.Config.ElemSize,
-				Y: pShape.Y,
-				W: pShape.W - WidthA() - ctx.Config.ElemSize,
-				H: pShape.H,
-			}
+		offset := prev + i*ctx.Config.ElemSize
+		if f.Separator.Type == VERTICAL {
+			slots[i] = Rect{X: shape.X, Y: shape.Y + offset, W: shape.W, H: end - prev}
 		} else {
-			return Rect{
-				X: pShape.X,
-				Y: pShape.Y + HeightA() + ctx.Config.ElemSize,
-				W: pShape.W,
-				H: pShape.H - HeightA() - ctx.Config.ElemSize,
-			}
+			slots[i] = Rect{X: shape.X + offset, Y: shape.Y, W: end - prev, H: shape.H}
 		}
+		prev = end
 	}
+	return slots
 }
 
-func (f *Frame) SeparatorShape(ctx *Context) Rect {
-	WidthA := func() int {
-		return ext.IMax(int(float64(f.Shape.W)*f.Separator.Ratio), ctx.Config.ElemSize) - ctx.Config.ElemSize
-	}
-	HeightA := func() int {
-		return ext.IMax(int(float64(f.Shape.H)*f.Separator.Ratio), ctx.Config.ElemSize) - ctx.Config.ElemSize
+// SeparatorShape returns the shape of the separator after child i of a split, or the tab strip of a tab group.
+func (f *Frame) SeparatorShape(ctx *Context, i int) Rect {
+	if f.Separator.Type == TABBED {
+		return f.TabStripShape(ctx)
 	}
+	a := f.ChildSlots(ctx, f.Shape)[i]
 	if f.Separator.Type == HORIZONTAL {
 		return Rect{
-			X: f.Shape.X + WidthA() - ctx.Config.InternalPadding,
+			X: a.X + a.W - ctx.Config.InternalPadding,
 			Y: f.Shape.Y - ctx.Config.InternalPadding,
 			W: ctx.Config.ElemSize + ctx.Config.InternalPadding,
 			H: f.Shape.H + ctx.Config.InternalPadding,
@@ -529,7 +754,7 @@ func (f *Frame) SeparatorShape(ctx *Context) Rect {
 	} else {
 		return Rect{
 			X: f.Shape.X - ctx.Config.InternalPadding,
-			Y: f.Shape.Y + HeightA() - ctx.Config.InternalPadding,
+			Y: a.Y + a.H - ctx.Config.InternalPadding,
 			W: f.Shape.W + ctx.Config.InternalPadding,
 			H: ctx.Config.ElemSize + ctx.Config.InternalPadding,
 		}
@@ -541,13 +766,16 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 	xevent.ConfigureRequestFun(
 		func(X *xgbutil.XUtil, ev xevent.ConfigureRequestEvent) {
 			f := ctx.Get(window)
-			if f != nil && !f.IsOrphan() && f.IsRoot() && f.IsLeaf() {
+			if f != nil && !f.IsOrphan() && f.IsRoot() && f.IsLeaf() && f.Container.WmState.IsNormal() {
 				fShape := f.Shape
 				fShape.X = int(ev.X)
 				fShape.Y = int(ev.Y)
 				fShape.W = int(ev.Width)
 				fShape.H = int(ev.Height)
 				cShape := ContainerShapeFromRoot(ctx, fShape)
+				if f.Container.Decorations.Hidden {
+					cShape = fShape
+				}
 				cShape.X = ext.IMax(cShape.X, 0)
 				cShape.Y = ext.IMax(cShape.Y, 0)
 				f.Container.MoveResize(ctx, cShape.X, cShape.Y, cShape.W, cShape.H)
@@ -566,18 +794,67 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			}
 			switch name {
 			case "_NET_WM_STATE":
+				HandleWmStateMessage(ctx, ctx.Get(window), ev)
+				ctx.RaiseLock() // in case the fullscreen message happens in background
+			// Requests from pagers and tools like wmctrl or xdotool, sent to the root but dispatched by window
+			case "_NET_ACTIVE_WINDOW":
 				f := ctx.Get(window)
-				if f.IsOrphan() {
+				if ctx.Locked || f == nil || f.IsOrphan() {
 					return
 				}
-				// TODO: This is a dirty hack, instead of properly implementing ewmh
-				// we toggle minimazation state to get internal media players to resize
-				f.Container.ChangeMinimizationState(ctx)
-				f.Container.ChangeMinimizationState(ctx)
-				ctx.RaiseLock() // in case the fullscreen message happens in background
+				if f.Container.Hidden {
+					f.Container.ChangeMinimizationState(ctx)
+				}
+				f.FocusRaise(ctx)
+			case "_NET_WM_DESKTOP":
+				f := ctx.Get(window)
+				if ctx.Locked || f == nil || f.IsOrphan() || len(ev.Data.Data32) == 0 {
+					return
+				}
+				f.Container.MoveToWorkspace(ctx, int(ev.Data.Data32[0]))
+			case "_NET_CLOSE_WINDOW":
+				f := ctx.Get(window)
+				if ctx.Locked || f == nil || f.IsOrphan() {
+					return
+				}
+				f.Close(ctx)
 			}
 		}).Connect(ctx.X, window)
 
+	xevent.PropertyNotifyFun(
+		func(X *xgbutil.XUtil, ev xevent.PropertyNotifyEvent) {
+			name, err := xprop.AtomName(X, ev.Atom)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			switch name {
+			case "WM_NORMAL_HINTS":
+				f := ctx.Get(window)
+				if f == nil {
+					return
+				}
+				f.Hints = nil
+				if !f.IsOrphan() {
+					f.MoveResize(ctx)
+				}
+			case "WM_HINTS":
+				f := ctx.Get(window)
+				if f == nil {
+					return
+				}
+				hints, err := icccm.WmHintsGet(X, window)
+				f.SetUrgent(ctx, err == nil && hints.Flags&icccm.HintUrgency > 0)
+			case "_NET_WM_NAME", "WM_NAME":
+				f := ctx.Get(window)
+				if f == nil || f.IsOrphan() {
+					return
+				}
+				f.Container.UpdateTabs(ctx)
+			}
+		}).Connect(ctx.X, window)
+	ext.Logerr(xwindow.New(ctx.X, window).Listen(xproto.EventMaskPropertyChange))
+
 	xevent.UnmapNotifyFun(
 		func(X *xgbutil.XUtil, ev xevent.UnmapNotifyEvent) {
 			// Keep track of how many unmaps we've received since we get a notification
@@ -596,10 +873,13 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 					nf.Focus(ctx)
 				} else {
 					ext.Focus(xwindow.New(ctx.X, ctx.X.RootWin()))
+					ctx.SetActiveWindow(0)
 				}
 			}
 
 			f.Orphan(ctx)
+			ctx.UpdateClientList()
+			ctx.EmitWindow(ipc.EventUnmanage, window)
 			ctx.RaiseLock()
 		}).Connect(ctx.X, window)
 
@@ -608,6 +888,8 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			f := ctx.Get(window)
 			f.Destroy(ctx)
 			delete(ctx.Tracked, window)
+			ctx.UpdateClientList()
+			ctx.EmitWindow(ipc.EventDestroy, window)
 			ctx.RaiseLock()
 		}).Connect(ctx.X, window)
 
@@ -622,7 +904,14 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 		}).Connect(ctx.X, window, ctx.Config.ButtonClick, true, true)
 	ext.Logerr(err)
 
-	err = keybind.KeyReleaseFun(
+	AddDropHook(ctx, window)
+	return AddWindowKeyHooks(ctx, window)
+}
+
+// AddWindowKeyHooks registers the key bindings for a window, separate from the other
+// hooks so they can be connected again when the config changes.
+func AddWindowKeyHooks(ctx *Context, window xproto.Window) error {
+	err := keybind.KeyReleaseFun(
 		func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
 			if ctx.Locked {
 				return
@@ -661,44 +950,7 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 		if ctx.Locked {
 			return
 		}
-
-		if ctx.Yanked == nil {
-			return
-		}
-		defer func() { ctx.Yanked = nil }()
-
-		target := ctx.Get(window)
-		if target == nil {
-			log.Println("could not find target window for yank attach")
-		}
-		source := func() *Frame {
-			if ctx.Yanked.Container != nil && ctx.Yanked.Container.Root != nil {
-				if ctx.Yanked.Container == target.Container {
-					log.Println("tried to yank container into itself")
-					return nil
-				}
-				s := ctx.Yanked.Container.Root
-				s.Unmap(ctx)
-				ctx.Yanked.Container.Destroy(ctx)
-				return s
-			} else {
-				s := ctx.Get(ctx.Yanked.Window)
-				if s == target {
-					log.Println("tried to yank frame into itself")
-					return nil
-				}
-				if s != nil {
-					s.Orphan(ctx)
-				}
-				return s
-			}
-		}()
-		if source == nil {
-			log.Println("could not find source window for yank attach")
-			return
-		}
-
-		AttachWindow(ctx, target, partition, 0, source)
+		PasteYanked(ctx, ctx.Get(window), partition)
 	}
 
 	err = keybind.KeyReleaseFun(
@@ -713,17 +965,19 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 		}).Connect(ctx.X, window, ctx.Config.CopySelectVertical.Data, true)
 	ext.Logerr(err)
 
+	err = keybind.KeyReleaseFun(
+		func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			yankAttach(window, TABBED)
+		}).Connect(ctx.X, window, ctx.Config.CopySelectTabbed.Data, true)
+	ext.Logerr(err)
+
 	err = keybind.KeyReleaseFun(
 		func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
 			if ctx.Locked {
 				return
 			}
 
-			f := ctx.Get(window)
-			if f.IsLeaf() && !f.IsRoot() {
-				f.Orphan(ctx)
-				NewWindow(ctx, f.Window.Id)
-			}
+			ctx.Get(window).Pop(ctx)
 		}).Connect(ctx.X, window, ctx.Config.PopFrame.Data, true)
 	ext.Logerr(err)
 
@@ -751,6 +1005,7 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			if f.IsOrphan() {
 				return
 			}
+			ctx.RecordLayout()
 			if f.Container.Expanded == f || f.IsRoot() {
 				f.Container.Expanded = nil
 			} else {
@@ -800,21 +1055,7 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			if f.IsOrphan() {
 				return
 			}
-			screen, _, _ := ctx.GetScreenForShape(f.Container.Shape)
-			if f.Container.Shape == AnchorShape(ctx, screen, FULL) {
-				s := AnchorShape(ctx, screen, TOP)
-				f.Container.MoveResizeShape(ctx, s)
-			} else if f.Container.Shape == AnchorShape(ctx, screen, TOP) {
-				raised := screen
-				raised.Y = raised.Y - raised.H
-				if nscreen, overlap, _ := ctx.GetScreenForShape(raised); overlap > 0 && nscreen != screen {
-					f.Container.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, BOTTOM))
-				}
-			} else if f.Container.Shape == AnchorShape(ctx, screen, BOTTOM) {
-				f.Container.MoveResizeShape(ctx, f.Container.RestingShape(ctx, screen))
-			} else {
-				f.Container.MoveResizeShape(ctx, AnchorShape(ctx, screen, FULL))
-			}
+			f.Container.MoveToAnchor(ctx, TOP)
 		}).Connect(ctx.X, window, ctx.Config.WindowUp.Data, true)
 	ext.Logerr(err)
 
@@ -827,18 +1068,7 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			if f.IsOrphan() {
 				return
 			}
-			screen, _, _ := ctx.GetScreenForShape(f.Container.Shape)
-			if f.Container.Shape == AnchorShape(ctx, screen, FULL) || f.Container.Shape == AnchorShape(ctx, screen, TOP) {
-				f.Container.MoveResizeShape(ctx, f.Container.RestingShape(ctx, screen))
-			} else if f.Container.Shape == AnchorShape(ctx, screen, BOTTOM) {
-				lowered := screen
-				lowered.Y = lowered.Y + lowered.H
-				if nscreen, overlap, _ := ctx.GetScreenForShape(lowered); overlap > 0 && nscreen != screen {
-					f.Container.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, TOP))
-				}
-			} else {
-				f.Container.MoveResizeShape(ctx, AnchorShape(ctx, screen, BOTTOM))
-			}
+			f.Container.MoveToAnchor(ctx, BOTTOM)
 		}).Connect(ctx.X, window, ctx.Config.WindowDown.Data, true)
 	ext.Logerr(err)
 
@@ -851,18 +1081,7 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			if f.IsOrphan() {
 				return
 			}
-			screen, _, _ := ctx.GetScreenForShape(f.Container.Shape)
-			if f.Container.Shape == AnchorShape(ctx, screen, RIGHT) {
-				f.Container.MoveResizeShape(ctx, f.Container.RestingShape(ctx, screen))
-			} else if f.Container.Shape == AnchorShape(ctx, screen, LEFT) {
-				lefted := screen
-				lefted.X = lefted.X - lefted.W
-				if nscreen, overlap, _ := ctx.GetScreenForShape(lefted); overlap > 0 && nscreen != screen {
-					f.Container.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, RIGHT))
-				}
-			} else {
-				f.Container.MoveResizeShape(ctx, AnchorShape(ctx, screen, LEFT))
-			}
+			f.Container.MoveToAnchor(ctx, LEFT)
 		}).Connect(ctx.X, window, ctx.Config.WindowLeft.Data, true)
 	ext.Logerr(err)
 
@@ -875,21 +1094,31 @@ func AddWindowHook(ctx *Context, window xproto.Window) error {
 			if f.IsOrphan() {
 				return
 			}
-			screen, _, _ := ctx.GetScreenForShape(f.Container.Shape)
-			if f.Container.Shape == AnchorShape(ctx, screen, LEFT) {
-				f.Container.MoveResizeShape(ctx, f.Container.RestingShape(ctx, screen))
-			} else if f.Container.Shape == AnchorShape(ctx, screen, RIGHT) {
-				righted := screen
-				righted.X = righted.X + righted.W
-				if nscreen, overlap, _ := ctx.GetScreenForShape(righted); overlap > 0 && nscreen != screen {
-					f.Container.MoveResizeShape(ctx, AnchorShape(ctx, nscreen, LEFT))
-				}
-			} else {
-				f.Container.MoveResizeShape(ctx, AnchorShape(ctx, screen, RIGHT))
-			}
+			f.Container.MoveToAnchor(ctx, RIGHT)
 		}).Connect(ctx.X, window, ctx.Config.WindowRight.Data, true)
 	ext.Logerr(err)
 
+	arrange := map[string]func(f *Frame){
+		ctx.Config.RotateSplit.Data:    func(f *Frame) { f.Rotate(ctx) },
+		ctx.Config.FlipSplit.Data:      func(f *Frame) { f.Flip(ctx) },
+		ctx.Config.EqualizeSplits.Data: func(f *Frame) { f.Container.Equalize(ctx) },
+		ctx.Config.BalanceSplits.Data:  func(f *Frame) { f.Container.Balance(ctx) },
+	}
+	for k, v := range arrange {
+		fun := v // capture separately so we can use in closure
+		err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			if ctx.Locked {
+				return
+			}
+			f := ctx.Get(window)
+			if f.IsOrphan() {
+				return
+			}
+			fun(f)
+		}).Connect(ctx.X, window, k, true)
+		ext.Logerr(err)
+	}
+
 	for k, v := range ctx.Config.GotoKeys {
 		kref := k // capture separately so we can use in closure
 		vref := v // capture separately so we can use in closure
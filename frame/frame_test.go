@@ -0,0 +1,190 @@
+package frame
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func testContext() *Context {
+	return &Context{Config: Config{ElemSize: 10, TabHeight: 20}}
+}
+
+func split(partition PartitionType, weights []float64, children ...*Frame) *Frame {
+	f := &Frame{Children: children, Separator: Partition{Type: partition, Weights: weights}}
+	for _, child := range children {
+		child.Parent = f
+	}
+	return f
+}
+
+func closeTo(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestWeights(t *testing.T) {
+	cases := []struct {
+		weights []float64
+		n       int
+		want    []float64
+	}{
+		{[]float64{1, 3}, 2, []float64{.25, .75}},
+		{[]float64{.2, .3, .5}, 3, []float64{.2, .3, .5}},
+		{nil, 3, []float64{1. / 3, 1. / 3, 1. / 3}},
+		{[]float64{1, 2}, 3, []float64{1. / 3, 1. / 3, 1. / 3}},
+		{[]float64{0, 0}, 2, []float64{.5, .5}},
+		{[]float64{-1, 1}, 2, []float64{0, 1}},
+	}
+	for _, c := range cases {
+		children := make([]*Frame, c.n)
+		for i := range children {
+			children[i] = &Frame{}
+		}
+		f := split(HORIZONTAL, c.weights, children...)
+		if got := f.weights(); !closeTo(got, c.want) {
+			t.Errorf("weights of %v over %d children = %v, want %v", c.weights, c.n, got, c.want)
+		}
+	}
+}
+
+func TestChildSlots(t *testing.T) {
+	ctx := testContext()
+	shape := Rect{X: 100, Y: 50, W: 320, H: 200}
+
+	f := split(HORIZONTAL, []float64{.25, .25, .5}, &Frame{}, &Frame{}, &Frame{})
+	want := []Rect{
+		{X: 100, Y: 50, W: 75, H: 200},
+		{X: 185, Y: 50, W: 75, H: 200},
+		{X: 270, Y: 50, W: 150, H: 200},
+	}
+	if got := f.ChildSlots(ctx, shape); !reflect.DeepEqual(got, want) {
+		t.Errorf("horizontal slots = %v, want %v", got, want)
+	}
+
+	f = split(VERTICAL, nil, &Frame{}, &Frame{})
+	want = []Rect{
+		{X: 100, Y: 50, W: 320, H: 95},
+		{X: 100, Y: 155, W: 320, H: 95},
+	}
+	if got := f.ChildSlots(ctx, shape); !reflect.DeepEqual(got, want) {
+		t.Errorf("vertical slots = %v, want %v", got, want)
+	}
+
+	f = split(TABBED, nil, &Frame{}, &Frame{})
+	content := Rect{X: 100, Y: 70, W: 320, H: 180}
+	for i, got := range f.ChildSlots(ctx, shape) {
+		if got != content {
+			t.Errorf("tab %d slot = %v, want %v", i, got, content)
+		}
+	}
+
+	// Slots never overlap or go past the split, even when there is no room for the separators
+	f = split(HORIZONTAL, []float64{.5, .5, 0}, &Frame{}, &Frame{}, &Frame{})
+	for _, got := range f.ChildSlots(ctx, Rect{W: 15, H: 10}) {
+		if got.W < 0 {
+			t.Errorf("slot %v has a negative width", got)
+		}
+	}
+}
+
+func TestSplitWeight(t *testing.T) {
+	cases := []struct {
+		weights []float64
+		i, at   int
+		want    []float64
+	}{
+		{[]float64{1}, 0, 1, []float64{.5, .5}},
+		{[]float64{.5, .5}, 0, 1, []float64{.25, .25, .5}},
+		{[]float64{.5, .5}, 1, 1, []float64{.5, .25, .25}},
+		{[]float64{.2, .8}, 1, 2, []float64{.2, .4, .4}},
+	}
+	for _, c := range cases {
+		in := append([]float64(nil), c.weights...)
+		if got := splitWeight(in, c.i, c.at); !closeTo(got, c.want) {
+			t.Errorf("splitWeight(%v, %d, %d) = %v, want %v", c.weights, c.i, c.at, got, c.want)
+		}
+	}
+}
+
+func TestDropWeight(t *testing.T) {
+	cases := []struct {
+		weights []float64
+		i       int
+		want    []float64
+	}{
+		{[]float64{.5, .5}, 0, []float64{1}},
+		{[]float64{.2, .3, .5}, 2, []float64{.4, .6}},
+		{[]float64{.25, .5, .25}, 1, []float64{.5, .5}},
+		{[]float64{0, 0, 1}, 2, []float64{.5, .5}},
+	}
+	for _, c := range cases {
+		in := append([]float64(nil), c.weights...)
+		if got := dropWeight(in, c.i); !closeTo(got, c.want) {
+			t.Errorf("dropWeight(%v, %d) = %v, want %v", c.weights, c.i, got, c.want)
+		}
+	}
+}
+
+func TestSpliceWeight(t *testing.T) {
+	cases := []struct {
+		weights []float64
+		i       int
+		inner   []float64
+		want    []float64
+	}{
+		{[]float64{.5, .5}, 0, []float64{.5, .5}, []float64{.25, .25, .5}},
+		{[]float64{.2, .8}, 1, []float64{.25, .25, .5}, []float64{.2, .2, .2, .4}},
+		{[]float64{.2, .4, .4}, 1, []float64{.5, .5}, []float64{.2, .2, .2, .4}},
+		{[]float64{1}, 0, []float64{.3, .7}, []float64{.3, .7}},
+	}
+	for _, c := range cases {
+		if got := spliceWeight(c.weights, c.i, c.inner); !closeTo(got, c.want) {
+			t.Errorf("spliceWeight(%v, %d, %v) = %v, want %v", c.weights, c.i, c.inner, got, c.want)
+		}
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	ctx := testContext()
+
+	// The last child of the root split becomes the root
+	leaf := &Frame{}
+	c := &Container{}
+	c.Root = split(HORIZONTAL, []float64{1}, leaf)
+	c.Root.Container, leaf.Container = c, c
+	if moved := c.Root.Collapse(ctx); moved != leaf || c.Root != leaf || leaf.Parent != nil {
+		t.Errorf("root split was not replaced by its child")
+	}
+
+	// A child that splits another way takes the place of the split in its parent, keeping its share
+	a, b, d := &Frame{}, &Frame{}, &Frame{}
+	inner := split(HORIZONTAL, []float64{.5, .5}, a, b)
+	single := split(VERTICAL, []float64{1}, inner)
+	c = &Container{}
+	c.Root = split(VERTICAL, []float64{.3, .7}, d, single)
+	c.Root.Traverse(func(f *Frame) { f.Container = c })
+	c.Expanded = single
+	if moved := single.Collapse(ctx); moved != inner {
+		t.Errorf("moved %v, want the child that took the place of the split", moved)
+	}
+	if len(c.Root.Children) != 2 || c.Root.Children[1] != inner || inner.Parent != c.Root {
+		t.Errorf("child did not take the place of the split")
+	}
+	if !closeTo(c.Root.weights(), []float64{.3, .7}) {
+		t.Errorf("weights = %v, want [0.3 0.7]", c.Root.weights())
+	}
+	if c.Expanded != inner {
+		t.Errorf("expanded frame was not moved to the child")
+	}
+	if !single.IsOrphan() || single.Children != nil {
+		t.Errorf("collapsed split was not taken apart")
+	}
+}
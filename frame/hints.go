@@ -0,0 +1,189 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgbutil/icccm"
+	"github.com/levavakian/rowm/ext"
+)
+
+// SizeHints are the size constraints a client sets in WM_NORMAL_HINTS.
+// Unset fields are left at zero.
+type SizeHints struct {
+	MinW, MinH           int
+	MaxW, MaxH           int
+	BaseW, BaseH         int
+	IncW, IncH           int
+	MinAspect, MaxAspect float64
+}
+
+// LoadSizeHints reads the WM_NORMAL_HINTS of a window, falling back to no constraints.
+func LoadSizeHints(ctx *Context, f *Frame) SizeHints {
+	h := SizeHints{}
+	nh, err := icccm.WmNormalHintsGet(ctx.X, f.Window.Id)
+	if err != nil || nh == nil {
+		return h
+	}
+
+	if nh.Flags&icccm.SizeHintPMinSize > 0 {
+		h.MinW, h.MinH = int(nh.MinWidth), int(nh.MinHeight)
+	}
+	if nh.Flags&icccm.SizeHintPMaxSize > 0 {
+		h.MaxW, h.MaxH = int(nh.MaxWidth), int(nh.MaxHeight)
+	}
+	// The base size and minimum size stand in for each other when only one is set
+	if nh.Flags&icccm.SizeHintPBaseSize > 0 {
+		h.BaseW, h.BaseH = int(nh.BaseWidth), int(nh.BaseHeight)
+		if nh.Flags&icccm.SizeHintPMinSize == 0 {
+			h.MinW, h.MinH = h.BaseW, h.BaseH
+		}
+	} else {
+		h.BaseW, h.BaseH = h.MinW, h.MinH
+	}
+	if nh.Flags&icccm.SizeHintPResizeInc > 0 {
+		h.IncW, h.IncH = int(nh.WidthInc), int(nh.HeightInc)
+	}
+	if nh.Flags&icccm.SizeHintPAspect > 0 {
+		if nh.MinAspectDen > 0 {
+			h.MinAspect = float64(nh.MinAspectNum) / float64(nh.MinAspectDen)
+		}
+		if nh.MaxAspectDen > 0 {
+			h.MaxAspect = float64(nh.MaxAspectNum) / float64(nh.MaxAspectDen)
+		}
+	}
+	return h
+}
+
+// SizeHints returns the size constraints of a leaf, reading them from the client the first time.
+func (f *Frame) SizeHints(ctx *Context) SizeHints {
+	if f.Window == nil {
+		return SizeHints{}
+	}
+	if f.Hints == nil {
+		h := LoadSizeHints(ctx, f)
+		f.Hints = &h
+	}
+	return *f.Hints
+}
+
+// Constrain shrinks a shape to the largest size within it that the client accepts.
+// Shapes are never grown past what they were given, minimum sizes are kept by the separators and container instead.
+func (h SizeHints) Constrain(shape Rect) Rect {
+	if h.MaxW > 0 {
+		shape.W = ext.IMin(shape.W, h.MaxW)
+	}
+	if h.MaxH > 0 {
+		shape.H = ext.IMin(shape.H, h.MaxH)
+	}
+
+	if shape.H > 0 {
+		aspect := float64(shape.W) / float64(shape.H)
+		if h.MinAspect > 0 && aspect < h.MinAspect {
+			shape.H = int(float64(shape.W) / h.MinAspect)
+		} else if h.MaxAspect > 0 && aspect > h.MaxAspect {
+			shape.W = int(float64(shape.H) * h.MaxAspect)
+		}
+	}
+
+	// Round down to a whole number of increments, like character cells for terminals
+	if h.IncW > 1 && shape.W-h.BaseW >= h.IncW {
+		shape.W -= (shape.W - h.BaseW) % h.IncW
+	}
+	if h.IncH > 1 && shape.H-h.BaseH >= h.IncH {
+		shape.H -= (shape.H - h.BaseH) % h.IncH
+	}
+	return shape
+}
+
+// MinSize returns the smallest width and height a frame can have without squeezing any of its windows below their minimum size.
+func (f *Frame) MinSize(ctx *Context) (int, int) {
+	if f.IsLeaf() {
+		h := f.SizeHints(ctx)
+		return h.MinW, h.MinH
+	}
+
+	w, h := 0, 0
+	for i, child := range f.Children {
+		cw, ch := child.MinSize(ctx)
+		gap := 0
+		if i > 0 {
+			gap = ctx.Config.ElemSize
+		}
+		switch {
+		case f.IsTabbed():
+			w, h = ext.IMax(w, cw), ext.IMax(h, ch)
+		case f.Separator.Type == HORIZONTAL:
+			w, h = w+cw+gap, ext.IMax(h, ch)
+		default:
+			w, h = ext.IMax(w, cw), h+ch+gap
+		}
+	}
+	if f.IsTabbed() {
+		h += ctx.Config.TabHeight
+	}
+	return w, h
+}
+
+// minAlong returns the minimum size of a child in the direction a split lays out its children.
+func (f *Frame) minAlong(ctx *Context, child *Frame) int {
+	w, h := child.MinSize(ctx)
+	if f.Separator.Type == VERTICAL {
+		return h
+	}
+	return w
+}
+
+// MoveSeparator moves separator i of a split to pos, measured from the start of the split, trading space between
+// the children on either side of it. Neither side is squeezed below the minimum size of its windows, unless both can't fit.
+func (f *Frame) MoveSeparator(ctx *Context, i int, pos int) {
+	if f.IsTabbed() || i < 0 || i >= len(f.Children)-1 {
+		return
+	}
+	slots := f.ChildSlots(ctx, f.Shape)
+	origin, _ := f.alongSplit(f.Shape)
+	start, _ := f.alongSplit(slots[i])
+	bStart, bSize := f.alongSplit(slots[i+1])
+	start, end := start-origin, bStart+bSize-origin
+	total := end - start - ctx.Config.ElemSize
+	if total <= 0 {
+		return
+	}
+
+	size := ext.IClamp(pos-start, 0, total)
+	minA, minB := f.minAlong(ctx, f.Children[i]), f.minAlong(ctx, f.Children[i+1])
+	if minA+minB <= total {
+		size = ext.IClamp(size, minA, total-minB)
+	}
+	weights := f.weights()
+	sum := weights[i] + weights[i+1]
+	weights[i] = sum * float64(size) / float64(total)
+	weights[i+1] = sum - weights[i]
+	f.Separator.Weights = weights
+}
+
+// FitWeights moves the separators of a split as needed to keep its children from being squeezed below their minimum size.
+func (f *Frame) FitWeights(ctx *Context) {
+	if f.IsLeaf() || f.IsTabbed() {
+		return
+	}
+	origin, _ := f.alongSplit(f.Shape)
+	for i := 0; i < len(f.Children)-1; i++ {
+		start, size := f.alongSplit(f.ChildSlots(ctx, f.Shape)[i])
+		f.MoveSeparator(ctx, i, start+size-origin)
+	}
+}
+
+// MinShape is the smallest shape the container can be resized to while still fitting the minimum size of its windows.
+func (c *Container) MinShape(ctx *Context) Rect {
+	min := ctx.Config.MinShape()
+	if c.Root == nil {
+		return min
+	}
+
+	w, h := c.ActiveRoot().MinSize(ctx)
+	if !c.Decorations.Hidden {
+		w += 2 * ctx.Config.ElemSize
+		h += 3 * ctx.Config.ElemSize
+	}
+	min.W = ext.IMax(min.W, w)
+	min.H = ext.IMax(min.H, h)
+	return min
+}
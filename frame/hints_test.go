@@ -0,0 +1,78 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"testing"
+)
+
+func TestMoveSeparator(t *testing.T) {
+	ctx := testContext()
+	leaf := func(minW int) *Frame {
+		// Hints are already loaded, so the window is never asked for them
+		return &Frame{Window: &xwindow.Window{}, Hints: &SizeHints{MinW: minW}}
+	}
+
+	cases := []struct {
+		name   string
+		minA   int
+		minB   int
+		i, pos int
+		want   []float64
+	}{
+		{"moves freely", 0, 0, 0, 70, []float64{.35, .15, .5}},
+		{"stops at the start", 0, 0, 0, -50, []float64{0, .5, .5}},
+		{"stops at the next separator", 0, 0, 0, 500, []float64{.5, 0, .5}},
+		{"keeps the minimum before", 40, 0, 0, 10, []float64{.2, .3, .5}},
+		{"keeps the minimum after", 0, 40, 0, 100, []float64{.3, .2, .5}},
+		{"ignores minimums that can't fit", 60, 60, 0, 30, []float64{.15, .35, .5}},
+		{"second separator", 0, 0, 1, 150, []float64{.25, .45, .3}},
+	}
+	for _, c := range cases {
+		// 220 wide leaves 200 for the children after the two separators
+		f := split(HORIZONTAL, []float64{.25, .25, .5}, leaf(c.minA), leaf(c.minB), leaf(0))
+		f.Shape = Rect{X: 1000, W: 220, H: 100}
+		f.MoveSeparator(ctx, c.i, c.pos)
+		if !closeTo(f.Separator.Weights, c.want) {
+			t.Errorf("%s: weights = %v, want %v", c.name, f.Separator.Weights, c.want)
+		}
+	}
+}
+
+func TestMoveSeparatorOutOfRange(t *testing.T) {
+	ctx := testContext()
+	f := split(HORIZONTAL, []float64{.5, .5}, &Frame{}, &Frame{})
+	f.Shape = Rect{W: 210, H: 100}
+	for _, i := range []int{-1, 1} {
+		f.MoveSeparator(ctx, i, 50)
+		if !closeTo(f.Separator.Weights, []float64{.5, .5}) {
+			t.Errorf("separator %d moved the weights to %v", i, f.Separator.Weights)
+		}
+	}
+}
+
+func TestFitWeights(t *testing.T) {
+	ctx := testContext()
+	leaf := func(minW int) *Frame {
+		return &Frame{Window: &xwindow.Window{}, Hints: &SizeHints{MinW: minW}}
+	}
+
+	cases := []struct {
+		name string
+		mins []int
+		want []float64
+	}{
+		{"leaves fitting splits alone", []int{20, 20, 20}, []float64{.25, .25, .5}},
+		{"grows the first child", []int{60, 0, 0}, []float64{.3, .2, .5}},
+		{"grows a middle child", []int{0, 80, 0}, []float64{.1, .4, .5}},
+		{"grows the last child", []int{0, 0, 120}, []float64{.25, .15, .6}},
+	}
+	for _, c := range cases {
+		// 220 wide leaves 200 for the children after the two separators
+		f := split(HORIZONTAL, []float64{.25, .25, .5}, leaf(c.mins[0]), leaf(c.mins[1]), leaf(c.mins[2]))
+		f.Shape = Rect{W: 220, H: 100}
+		f.FitWeights(ctx)
+		if !closeTo(f.Separator.Weights, c.want) {
+			t.Errorf("%s: weights = %v, want %v", c.name, f.Separator.Weights, c.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package frame
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/levavakian/rowm/ext"
+	"log"
+	"sort"
+)
+
+// Snapshot is the arrangement of every tiled container at one point in time.
+// Floating containers are left out, they follow the windows they belong to.
+type Snapshot struct {
+	Containers []*Container     // The containers as they were, reused if they are still around
+	States     []ContainerState // The saved tree and shape of each container
+}
+
+// LayoutHistory keeps the layouts to go back to with undo, and forward to again with redo.
+type LayoutHistory struct {
+	Undo      []*Snapshot
+	Redo      []*Snapshot
+	restoring bool
+}
+
+// TakeSnapshot saves the arrangement of every tiled container, in a stable order so snapshots can be compared.
+func (ctx *Context) TakeSnapshot() *Snapshot {
+	containers := make([]*Container, 0, len(ctx.Containers))
+	for c := range ctx.Containers {
+		if c.Root != nil && !c.Floating {
+			containers = append(containers, c)
+		}
+	}
+	s := &Snapshot{
+		Containers: containers,
+		States:     make([]ContainerState, len(containers)),
+	}
+	for i, c := range containers {
+		s.States[i] = c.SaveState()
+	}
+	sort.Sort(s)
+	return s
+}
+
+func (s *Snapshot) Len() int {
+	return len(s.States)
+}
+
+func (s *Snapshot) Less(i, j int) bool {
+	return s.States[i].Root.FirstWindow() < s.States[j].Root.FirstWindow()
+}
+
+func (s *Snapshot) Swap(i, j int) {
+	s.Containers[i], s.Containers[j] = s.Containers[j], s.Containers[i]
+	s.States[i], s.States[j] = s.States[j], s.States[i]
+}
+
+// Equal checks if two snapshots have the same arrangement.
+func (s *Snapshot) Equal(o *Snapshot) bool {
+	a, errA := json.Marshal(s.States)
+	b, errB := json.Marshal(o.States)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// RecordLayout saves the current arrangement so it can be undone, it is called right before the layout changes.
+// Any layouts that were undone can no longer be redone.
+func (ctx *Context) RecordLayout() {
+	h := &ctx.LayoutHistory
+	if h.restoring {
+		return
+	}
+	s := ctx.TakeSnapshot()
+	if n := len(h.Undo); n > 0 && h.Undo[n-1].Equal(s) {
+		return
+	}
+	h.Undo = append(h.Undo, s)
+	ctx.trimHistory(&h.Undo)
+	h.Redo = nil
+}
+
+// trimHistory drops the oldest snapshots past UndoLimit.
+func (ctx *Context) trimHistory(snapshots *[]*Snapshot) {
+	if extra := len(*snapshots) - ctx.Config.UndoLimit; extra > 0 {
+		*snapshots = (*snapshots)[extra:]
+	}
+}
+
+// UndoLayout goes back to the arrangement before the last layout change.
+func (ctx *Context) UndoLayout() {
+	ctx.stepHistory(&ctx.LayoutHistory.Undo, &ctx.LayoutHistory.Redo)
+}
+
+// RedoLayout goes forward again to the arrangement before the last undo.
+func (ctx *Context) RedoLayout() {
+	ctx.stepHistory(&ctx.LayoutHistory.Redo, &ctx.LayoutHistory.Undo)
+}
+
+// stepHistory restores the latest snapshot in from, saving the current arrangement to to.
+func (ctx *Context) stepHistory(from, to *[]*Snapshot) {
+	current := ctx.TakeSnapshot()
+	// Snapshots taken before a change that never happened look just like the current layout
+	for len(*from) > 0 && (*from)[len(*from)-1].Equal(current) {
+		*from = (*from)[:len(*from)-1]
+	}
+	if len(*from) == 0 {
+		log.Println("no layout to go back to")
+		return
+	}
+	s := (*from)[len(*from)-1]
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, current)
+	ctx.trimHistory(to)
+	ctx.RestoreSnapshot(s)
+}
+
+// RestoreSnapshot puts the tiled containers back the way they were in a snapshot, as far as their windows
+// still exist. Windows that came up since then get a container of their own.
+func (ctx *Context) RestoreSnapshot(s *Snapshot) {
+	ctx.LayoutHistory.restoring = true
+	defer func() { ctx.LayoutHistory.restoring = false }()
+
+	// Take every tiled container apart, keeping the frames of their windows
+	leaves := make(map[xproto.Window]*Frame)
+	old := make(map[*Container]xproto.Window)
+	for c := range ctx.Containers {
+		if c.Root == nil || c.Floating {
+			continue
+		}
+		c.Root.Unmap(ctx)
+		c.Root.Traverse(func(f *Frame) {
+			if f.IsLeaf() {
+				leaves[f.Window.Id] = f
+				old[c] = f.Window.Id
+			}
+			for _, d := range f.Separator.Decorations {
+				d.Window.Destroy()
+			}
+			f.Separator.Decorations = nil
+			f.Parent = nil
+			f.Container = nil
+			f.TabActive = false
+		})
+		c.Root = nil
+		c.Expanded = nil
+	}
+
+	available := make(map[xproto.Window]bool)
+	for w := range leaves {
+		available[w] = true
+	}
+	used := make(map[*Container]bool)
+	for i, cs := range s.States {
+		fs := cs.Root.Prune(available)
+		if fs == nil {
+			continue
+		}
+
+		c := s.Containers[i]
+		recreated := false
+		if _, ok := old[c]; !ok || used[c] {
+			// The container is gone, for example after it was pasted into another one
+			c = &Container{Shape: cs.Shape}
+			ext.Logerr(GeneratePieces(ctx, c))
+			ctx.Containers[c] = struct{}{}
+			recreated = true
+		}
+		used[c] = true
+
+		c.BuildTree(ctx, fs, func(w xproto.Window) *Frame {
+			available[w] = false
+			f := leaves[w]
+			delete(leaves, w)
+			return f
+		})
+		c.ApplyState(ctx, cs)
+		c.UpdateWmState(ctx)
+		// Containers still around keep being minimized or in the scratchpad the way they are now
+		if recreated {
+			c.ApplyVisibility(ctx, cs)
+		}
+	}
+
+	for w, f := range leaves {
+		WrapWindow(ctx, &Container{Shape: ctx.DefaultShapeForScreen(ctx.LastFocusedScreen())}, w, f, false)
+	}
+
+	// Containers left empty go away, dialogs that belonged to them go with the window they were on
+	for c, w := range old {
+		if used[c] {
+			continue
+		}
+		c.Decorations.Destroy(ctx)
+		delete(ctx.Containers, c)
+		ctx.Taskbar.RemoveContainer(ctx, c)
+		for oc := range ctx.Containers {
+			if oc.TransientFor != c {
+				continue
+			}
+			oc.TransientFor = nil
+			if f := ctx.Get(w); f != nil && !f.IsOrphan() {
+				oc.TransientFor = f.Container
+			}
+		}
+	}
+	ctx.UpdateClientList()
+	if f := ctx.GetFocusedFrame(); f != nil && !f.IsOrphan() {
+		f.FocusRaise(ctx)
+	}
+}
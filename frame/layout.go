@@ -0,0 +1,340 @@
+package frame
+
+import (
+	"fmt"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/icccm"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+	"io/ioutil"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout is a split tree from the config that is launched all at once.
+// A leaf runs Command, a split divides its Children by Split ("horizontal" or "vertical") according to
+// their Weights, or stacks them as tabs ("tabbed"). Without Weights the children get equal shares.
+// Windows are matched to leaves by the pid their command was started with, or by Class for
+// programs that hand their window off to another process.
+type Layout struct {
+	Key      string // Optional key string to launch the layout with, only used on the top level
+	Split    string
+	Weights  []float64
+	Command  string
+	Class    string // WM_CLASS instance or class name to match instead of the pid
+	Children []*Layout
+}
+
+func (l *Layout) IsLeaf() bool {
+	return len(l.Children) == 0
+}
+
+// Weight returns the weight of child i of a split, children all weigh the same if no Weights were given.
+func (l *Layout) Weight(i int) float64 {
+	if len(l.Weights) != len(l.Children) {
+		return 1
+	}
+	return l.Weights[i]
+}
+
+// Type returns the partition type for a split.
+func (l *Layout) Type() (PartitionType, error) {
+	switch strings.ToLower(l.Split) {
+	case "horizontal":
+		return HORIZONTAL, nil
+	case "vertical":
+		return VERTICAL, nil
+	case "tabbed":
+		return TABBED, nil
+	}
+	return HORIZONTAL, fmt.Errorf("Split must be horizontal, vertical or tabbed, not %q", l.Split)
+}
+
+// Check makes sure every node of the layout is either a leaf with a command or a complete split.
+func (l *Layout) Check() error {
+	if l.IsLeaf() {
+		if l.Command == "" {
+			return fmt.Errorf("leaf has no Command")
+		}
+		return nil
+	}
+
+	if l.Command != "" || l.Class != "" {
+		return fmt.Errorf("split can not have a Command or Class")
+	}
+	if _, err := l.Type(); err != nil {
+		return err
+	}
+
+	if len(l.Children) < 2 {
+		return fmt.Errorf("split needs at least two Children")
+	}
+	if len(l.Weights) > 0 && len(l.Weights) != len(l.Children) {
+		return fmt.Errorf("split has %d Weights for %d Children", len(l.Weights), len(l.Children))
+	}
+	for _, w := range l.Weights {
+		if w <= 0 {
+			return fmt.Errorf("Weights must be above 0, not %v", w)
+		}
+	}
+	for i, child := range l.Children {
+		if child == nil {
+			return fmt.Errorf("Children[%d] is empty", i)
+		}
+		if err := child.Check(); err != nil {
+			return fmt.Errorf("Children[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Traverse visits every node of the layout along with its parent.
+func (l *Layout) Traverse(fun func(l, parent *Layout)) {
+	var visit func(l, parent *Layout)
+	visit = func(l, parent *Layout) {
+		if l == nil {
+			return
+		}
+		fun(l, parent)
+		for _, child := range l.Children {
+			visit(child, l)
+		}
+	}
+	visit(l, nil)
+}
+
+// PendingLayout is a launched layout whose leaves are filled in as their windows are mapped.
+type PendingLayout struct {
+	Root      *Layout
+	Container *Container
+	Started   time.Time
+	Parents   map[*Layout]*Layout
+	Pids      map[*Layout]int
+	Placed    map[*Layout]xproto.Window
+}
+
+// LaunchLayout starts the command of every leaf of the named layout.
+// Their windows are put into a new container as they show up.
+func (ctx *Context) LaunchLayout(name string) error {
+	l, ok := ctx.Config.Layouts[name]
+	if !ok {
+		return fmt.Errorf("no layout named %q", name)
+	}
+
+	pl := &PendingLayout{
+		Root:    &l,
+		Started: time.Now(),
+		Parents: make(map[*Layout]*Layout),
+		Pids:    make(map[*Layout]int),
+		Placed:  make(map[*Layout]xproto.Window),
+	}
+	pl.Root.Traverse(func(l, parent *Layout) {
+		pl.Parents[l] = parent
+		if !l.IsLeaf() {
+			return
+		}
+
+		cmd := exec.Command("bash", "-c", l.Command)
+		if err := cmd.Start(); err != nil {
+			log.Println(err)
+			return
+		}
+		pl.Pids[l] = cmd.Process.Pid
+		go func() {
+			cmd.Wait()
+		}()
+	})
+
+	ctx.PendingLayouts = append(ctx.PendingLayouts, pl)
+	return nil
+}
+
+// PlaceLayoutWindow puts a new window into the slot of a pending layout that launched it, if any.
+func PlaceLayoutWindow(ctx *Context, window xproto.Window) *Frame {
+	// Forget about layouts that are complete or have waited too long
+	pending := ctx.PendingLayouts[:0]
+	for _, pl := range ctx.PendingLayouts {
+		if len(pl.Placed) < len(pl.Pids) && time.Since(pl.Started) < ctx.Config.LayoutTimeout {
+			pending = append(pending, pl)
+		}
+	}
+	ctx.PendingLayouts = pending
+	if len(pending) == 0 {
+		return nil
+	}
+
+	pid, _ := ewmh.WmPidGet(ctx.X, window)
+	class, _ := icccm.WmClassGet(ctx.X, window)
+
+	// Prefer matching by pid since classes are shared between every window of a program
+	match := func(matches func(l *Layout, pid int) bool) (*PendingLayout, *Layout) {
+		for _, pl := range pending {
+			for l, lpid := range pl.Pids {
+				if _, placed := pl.Placed[l]; !placed && matches(l, lpid) {
+					return pl, l
+				}
+			}
+		}
+		return nil, nil
+	}
+	pl, leaf := match(func(l *Layout, lpid int) bool {
+		return pid != 0 && isDescendant(int(pid), lpid)
+	})
+	if leaf == nil && class != nil {
+		pl, leaf = match(func(l *Layout, lpid int) bool {
+			return l.Class != "" && (l.Class == class.Instance || l.Class == class.Class)
+		})
+	}
+	if leaf == nil {
+		return nil
+	}
+
+	f := pl.Place(ctx, leaf, window)
+	if f != nil {
+		pl.Placed[leaf] = window
+	}
+	return f
+}
+
+// Place attaches the window for a leaf next to the part of the container that already holds
+// its nearest placed relatives, so the tree ends up the same shape no matter the order windows arrive in.
+func (pl *PendingLayout) Place(ctx *Context, leaf *Layout, window xproto.Window) *Frame {
+	if _, ok := ctx.Containers[pl.Container]; !ok || pl.Container.Root == nil {
+		pl.Container = nil
+	}
+
+	// Frames for placed leaves under a layout node, skipping ones that have since left the container
+	placed := func(l *Layout) []*Frame {
+		frames := make([]*Frame, 0)
+		l.Traverse(func(lt, parent *Layout) {
+			w, ok := pl.Placed[lt]
+			if !ok || pl.Container == nil {
+				return
+			}
+			if f := ctx.Get(w); f != nil && f.Container == pl.Container {
+				frames = append(frames, f)
+			}
+		})
+		return frames
+	}
+
+	if len(placed(pl.Root)) == 0 {
+		f := NewContainer(ctx, window, nil)
+		if f != nil {
+			pl.Container = f.Container
+		}
+		return f
+	}
+
+	// Climb up to the first split with other children already placed, the window stands in for the rest of its child
+	node := leaf
+	for parent := pl.Parents[node]; parent != nil; node, parent = parent, pl.Parents[parent] {
+		targets := make([]*Frame, 0, len(parent.Children))
+		weights := make([]float64, 0, len(parent.Children))
+		at := 0
+		for i, child := range parent.Children {
+			if child == node {
+				at = len(targets)
+				weights = append(weights, parent.Weight(i))
+				continue
+			}
+			frames := placed(child)
+			if len(frames) == 0 {
+				continue
+			}
+			target := CommonAncestor(frames)
+			if target == nil {
+				return nil
+			}
+			targets = append(targets, target)
+			weights = append(weights, parent.Weight(i))
+		}
+		if len(targets) == 0 {
+			continue
+		}
+
+		partition, _ := parent.Type()
+		return attachAmong(ctx, targets, at, partition, window, weights)
+	}
+	return nil
+}
+
+// attachAmong puts a window at position at among the frames standing in for the placed children of a
+// layout split. The first two get a split of their own, which later ones join. The split is then given
+// the weights of the placed children.
+func attachAmong(ctx *Context, targets []*Frame, at int, partition PartitionType, window xproto.Window, weights []float64) *Frame {
+	c := targets[0].Container
+	nf := &Frame{Window: xwindow.New(ctx.X, window)}
+	if len(targets) == 1 {
+		// Never join an existing split, the frames of a layout node have to share an ancestor of their own
+		targets[0].Insert(ctx, partition, nf, at == 0, false)
+	} else if at < len(targets) {
+		targets[at].Insert(ctx, partition, nf, true, true)
+	} else {
+		targets[at-1].Insert(ctx, partition, nf, false, true)
+	}
+	ap := nf.Parent
+	// Frames moved around since they were placed can leave the split with other children
+	if len(ap.Children) == len(weights) {
+		ap.Separator.Weights = weights
+		ap.NormalizeWeights()
+	}
+
+	nf.Shape = nf.CalcShape(ctx)
+	ctx.Tracked[window] = nf
+	if err := ext.MapChecked(nf.Window); err != nil {
+		log.Println("attachAmong:", window, "could not be mapped")
+		return nil
+	}
+	ext.Logerr(AddWindowHook(ctx, window))
+	nf.Map()
+
+	ap.MoveResize(ctx)
+	c.Raise(ctx)
+	nf.Focus(ctx)
+	return nf
+}
+
+// CommonAncestor returns the lowest frame that all the input frames descend from.
+func CommonAncestor(frames []*Frame) *Frame {
+	ancestor := frames[0]
+	for _, f := range frames[1:] {
+		above := make(map[*Frame]bool)
+		for a := ancestor; a != nil; a = a.Parent {
+			above[a] = true
+		}
+		for ancestor = f; ancestor != nil && !above[ancestor]; ancestor = ancestor.Parent {
+		}
+		if ancestor == nil {
+			return nil
+		}
+	}
+	return ancestor
+}
+
+// isDescendant checks whether a process is the ancestor process or one of its children,
+// since the command may have been started through a shell or wrapper script.
+func isDescendant(pid, ancestor int) bool {
+	for pid > 1 {
+		if pid == ancestor {
+			return true
+		}
+		stat, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return false
+		}
+		// The command name is in parens and can contain spaces, the parent pid is the second field after it
+		fields := strings.Fields(string(stat[strings.LastIndex(string(stat), ")")+1:]))
+		if len(fields) < 2 {
+			return false
+		}
+		if pid, err = strconv.Atoi(fields[1]); err != nil {
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package frame
+
+import (
+	"testing"
+)
+
+func TestLayoutCheck(t *testing.T) {
+	leaf := func() *Layout {
+		return &Layout{Command: "true"}
+	}
+	cases := []struct {
+		name   string
+		layout *Layout
+		ok     bool
+	}{
+		{"leaf", leaf(), true},
+		{"leaf without command", &Layout{}, false},
+		{"children", &Layout{Split: "horizontal", Children: []*Layout{leaf(), leaf(), leaf()}}, true},
+		{"weighted children", &Layout{Split: "vertical", Weights: []float64{1, 2}, Children: []*Layout{leaf(), leaf()}}, true},
+		{"one child", &Layout{Split: "horizontal", Children: []*Layout{leaf()}}, false},
+		{"weights don't match", &Layout{Split: "horizontal", Weights: []float64{1}, Children: []*Layout{leaf(), leaf()}}, false},
+		{"zero weight", &Layout{Split: "horizontal", Weights: []float64{1, 0}, Children: []*Layout{leaf(), leaf()}}, false},
+		{"bad child", &Layout{Split: "horizontal", Children: []*Layout{leaf(), {}}}, false},
+		{"bad split", &Layout{Split: "diagonal", Children: []*Layout{leaf(), leaf()}}, false},
+	}
+	for _, c := range cases {
+		if err := c.layout.Check(); (err == nil) != c.ok {
+			t.Errorf("%s: Check() = %v", c.name, err)
+		}
+	}
+}
+
+func TestLayoutWeight(t *testing.T) {
+	a, b := &Layout{Command: "a"}, &Layout{Command: "b"}
+	if l := (&Layout{Weights: []float64{3, 2}, Children: []*Layout{a, b}}); l.Weight(0) != 3 || l.Weight(1) != 2 {
+		t.Errorf("Weight does not follow the Weights given")
+	}
+	if l := (&Layout{Children: []*Layout{a, b}}); l.Weight(0) != 1 || l.Weight(1) != 1 {
+		t.Errorf("Weight does not default to 1 without Weights")
+	}
+}
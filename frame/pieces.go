@@ -126,10 +126,38 @@ func GeneratePieces(ctx *Context, c *Container) error {
 	return err
 }
 
+// UpdateColors repaints the decorations of the container and its separators from the config.
+func (c *Container) UpdateColors(ctx *Context) {
+	if c.Decorations.Generated() {
+		c.Decorations.Grab.SetColor(ctx.Config.GrabColor)
+		c.Decorations.Top.SetColor(ctx.Config.SeparatorColor)
+		c.Decorations.Bottom.SetColor(ctx.Config.SeparatorColor)
+		c.Decorations.Left.SetColor(ctx.Config.SeparatorColor)
+		c.Decorations.Right.SetColor(ctx.Config.SeparatorColor)
+		c.Decorations.BottomRight.SetColor(ctx.Config.ResizeColor)
+		c.Decorations.BottomLeft.SetColor(ctx.Config.ResizeColor)
+		c.Decorations.TopRight.SetColor(ctx.Config.ResizeColor)
+		c.Decorations.TopLeft.SetColor(ctx.Config.ResizeColor)
+		c.Decorations.Close.SetColor(ctx.Config.CloseColor)
+		c.Decorations.Maximize.SetColor(ctx.Config.MaximizeColor)
+		c.Decorations.Minimize.SetColor(ctx.Config.MinimizeColor)
+	}
+	c.Root.Traverse(func(f *Frame) {
+		if f.IsTabbed() {
+			f.DrawTabStrip(ctx)
+			return
+		}
+		for i := range f.Separator.Decorations {
+			f.Separator.Decorations[i].SetColor(ctx.Config.SeparatorColor)
+		}
+	})
+}
+
 func (c *Container) AddGrabHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.Grab.Window.Id, c.Decorations.Grab.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
@@ -187,13 +215,14 @@ func (c *Container) AddTopHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.Top.Window.Id, c.Decorations.Top.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
 			origYEnd := c.DragContext.Container.Y + c.DragContext.Container.H
-			h := ext.IMax(origYEnd-rY, ctx.Config.MinShape().H)
+			h := ext.IMax(origYEnd-rY, c.MinShape(ctx).H)
 			y := origYEnd - h
 			c.MoveResize(ctx, c.DragContext.Container.X, y, c.DragContext.Container.W, h)
 		},
@@ -207,12 +236,13 @@ func (c *Container) AddBottomHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.Bottom.Window.Id, c.Decorations.Bottom.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
-			h := ext.IMax(rY-c.DragContext.Container.Y, ctx.Config.MinShape().H)
+			h := ext.IMax(rY-c.DragContext.Container.Y, c.MinShape(ctx).H)
 			c.MoveResize(ctx, c.DragContext.Container.X, c.DragContext.Container.Y, c.DragContext.Container.W, h)
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
@@ -225,12 +255,13 @@ func (c *Container) AddRightHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.Right.Window.Id, c.Decorations.Right.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
-			w := ext.IMax(rX-c.DragContext.Container.X, ctx.Config.MinShape().W)
+			w := ext.IMax(rX-c.DragContext.Container.X, c.MinShape(ctx).W)
 			c.MoveResize(ctx, c.DragContext.Container.X, c.DragContext.Container.Y, w, c.DragContext.Container.H)
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
@@ -243,13 +274,14 @@ func (c *Container) AddLeftHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.Left.Window.Id, c.Decorations.Left.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
 			origXEnd := c.DragContext.Container.X + c.DragContext.Container.W
-			w := ext.IMax(origXEnd-rX, ctx.Config.MinShape().W)
+			w := ext.IMax(origXEnd-rX, c.MinShape(ctx).W)
 			x := origXEnd - w
 			c.MoveResize(ctx, x, c.DragContext.Container.Y, w, c.DragContext.Container.H)
 		},
@@ -263,13 +295,14 @@ func (c *Container) AddBottomRightHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.BottomRight.Window.Id, c.Decorations.BottomRight.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
-			w := ext.IMax(rX-c.DragContext.Container.X, ctx.Config.MinShape().W)
-			h := ext.IMax(rY-c.DragContext.Container.Y, ctx.Config.MinShape().H)
+			w := ext.IMax(rX-c.DragContext.Container.X, c.MinShape(ctx).W)
+			h := ext.IMax(rY-c.DragContext.Container.Y, c.MinShape(ctx).H)
 			c.MoveResize(ctx, c.DragContext.Container.X, c.DragContext.Container.Y, w, h)
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
@@ -282,15 +315,16 @@ func (c *Container) AddBottomLeftHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.BottomLeft.Window.Id, c.Decorations.BottomLeft.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
 			origXEnd := c.DragContext.Container.X + c.DragContext.Container.W
-			w := ext.IMax(origXEnd-rX, ctx.Config.MinShape().W)
+			w := ext.IMax(origXEnd-rX, c.MinShape(ctx).W)
 			x := origXEnd - w
-			h := ext.IMax(rY-c.DragContext.Container.Y, ctx.Config.MinShape().H)
+			h := ext.IMax(rY-c.DragContext.Container.Y, c.MinShape(ctx).H)
 			c.MoveResize(ctx, x, c.DragContext.Container.Y, w, h)
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
@@ -303,14 +337,15 @@ func (c *Container) AddTopRightHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.TopRight.Window.Id, c.Decorations.TopRight.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
 		},
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
 			origYEnd := c.DragContext.Container.Y + c.DragContext.Container.H
-			w := ext.IMax(rX-c.DragContext.Container.X, ctx.Config.MinShape().W)
-			h := ext.IMax(origYEnd-rY, ctx.Config.MinShape().H)
+			w := ext.IMax(rX-c.DragContext.Container.X, c.MinShape(ctx).W)
+			h := ext.IMax(origYEnd-rY, c.MinShape(ctx).H)
 			y := origYEnd - h
 			c.MoveResize(ctx, c.DragContext.Container.X, y, w, h)
 		},
@@ -324,6 +359,7 @@ func (c *Container) AddTopLeftHook(ctx *Context) {
 	mousebind.Drag(
 		ctx.X, c.Decorations.TopLeft.Window.Id, c.Decorations.TopLeft.Window.Id, ctx.Config.ButtonDrag, true,
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			ctx.RecordLayout()
 			c.DragContext = GenerateDragContext(ctx, c, nil, rX, rY)
 			c.RaiseFindFocus(ctx)
 			return true, ctx.Cursors[xcursor.Circle]
@@ -331,8 +367,8 @@ func (c *Container) AddTopLeftHook(ctx *Context) {
 		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
 			origYEnd := c.DragContext.Container.Y + c.DragContext.Container.H
 			origXEnd := c.DragContext.Container.X + c.DragContext.Container.W
-			w := ext.IMax(origXEnd-rX, ctx.Config.MinShape().W)
-			h := ext.IMax(origYEnd-rY, ctx.Config.MinShape().H)
+			w := ext.IMax(origXEnd-rX, c.MinShape(ctx).W)
+			h := ext.IMax(origYEnd-rY, c.MinShape(ctx).H)
 			y := origYEnd - h
 			x := origXEnd - w
 			c.MoveResize(ctx, x, y, w, h)
@@ -4,69 +4,138 @@ import (
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/BurntSushi/xgbutil/xwindow"
 	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
 	"log"
 )
 
+// AttachWindow splits a leaf with a window, or with an existing frame, putting it after the leaf.
+// If the leaf is already in a split of the same type, the window joins that split instead.
 func AttachWindow(ctx *Context, target *Frame, partitition PartitionType, window xproto.Window, existing *Frame) *Frame {
 	if !target.IsLeaf() {
 		log.Println("attach point is not leaf")
 		return nil
 	}
 
-	ap := target
-	ap.Separator.Type = partitition
-	ap.Separator.Ratio = .5
-	ap.CreateSeparatorDecoration(ctx)
+	nf := existing
+	if nf == nil {
+		nf = &Frame{Window: xwindow.New(ctx.X, window)}
+	}
+	target.Insert(ctx, partitition, nf, false, true)
+	if existing == nil {
+		nf.Shape = nf.CalcShape(ctx)
+		ctx.Tracked[window] = nf
 
-	// Move current window to child A
-	ca := &Frame{
-		Window:    ap.Window,
-		Parent:    ap,
-		Container: ap.Container,
+		if err := ext.MapChecked(nf.Window); err != nil {
+			log.Println("AttachWindow:", window, "could not be mapped")
+			return nil
+		}
+
+		err := AddWindowHook(ctx, window)
+		if err != nil {
+			log.Println("failed to add window hooks", err)
+		}
 	}
-	ap.ChildA = ca
-	ap.Window = nil
-	ca.Shape = ca.CalcShape(ctx)
-	ctx.Tracked[ca.Window.Id] = ca
+	nf.Map()
 
-	// Add new window as child B
-	cb := func() *Frame {
-		if existing == nil {
-			nf := &Frame{
-				Window:    xwindow.New(ctx.X, window),
-				Parent:    ap,
-				Container: ap.Container,
-			}
-			ap.ChildB = nf
-			nf.Shape = nf.CalcShape(ctx)
-			ctx.Tracked[window] = nf
+	nf.Parent.MoveResize(ctx)
+	nf.Container.Raise(ctx)
+	nf.Find(func(ff *Frame) bool { return ff.IsLeaf() }).Focus(ctx)
+	return nf
+}
 
-			if err := ext.MapChecked(nf.Window); err != nil {
-				log.Println("NewContainer:", window, "could not be mapped")
-				return nil
-			}
+// Insert puts a frame next to f, after it or before it, splitting the space f has with the given partition.
+// If merge is set and f is already in a split of that type, the frame joins that split and takes half of f's share.
+// Otherwise f is wrapped in a new split that takes its place.
+func (f *Frame) Insert(ctx *Context, partition PartitionType, nf *Frame, before, merge bool) {
+	c := f.Container
+	nf.Traverse(func(ft *Frame) {
+		ft.Container = c
+	})
+
+	p := f.Parent
+	if !merge || p == nil || p.Separator.Type != partition || c.Expanded == f {
+		p = &Frame{
+			Shape:     f.Shape,
+			Parent:    f.Parent,
+			Container: c,
+			Children:  []*Frame{f},
+			Separator: Partition{
+				Type:    partition,
+				Weights: []float64{1},
+			},
+		}
+		if f.Parent == nil {
+			c.Root = p
+		} else {
+			f.Parent.Children[f.Index()] = p
+		}
+		if c.Expanded == f {
+			c.Expanded = p
+		}
+		f.Parent = p
+		// The new split takes f's place as a tab
+		p.TabActive, f.TabActive = f.TabActive, false
+	}
 
-			err := AddWindowHook(ctx, window)
-			if err != nil {
-				log.Println("failed to add window hooks", err)
+	i := f.Index()
+	at := i + 1
+	if before {
+		at = i
+	}
+	p.Separator.Weights = splitWeight(p.weights(), i, at)
+	p.Children = append(p.Children[:at], append([]*Frame{nf}, p.Children[at:]...)...)
+	nf.Parent = p
+	nf.TabActive = false
+	p.UpdateDecorations(ctx)
+	c.UpdateTabs(ctx)
+}
+
+// splitWeight shares weight i with a new weight put in at position at.
+func splitWeight(weights []float64, i, at int) []float64 {
+	weights[i] /= 2
+	return append(weights[:at], append([]float64{weights[i]}, weights[at:]...)...)
+}
+
+// PasteYanked moves the yanked frame or container into the target frame, splitting it with the given partition.
+func PasteYanked(ctx *Context, target *Frame, partition PartitionType) *Frame {
+	if ctx.Yanked == nil {
+		return nil
+	}
+	defer func() { ctx.Yanked = nil }()
+
+	if target == nil {
+		log.Println("could not find target window for yank attach")
+		return nil
+	}
+	ctx.RecordLayout()
+	source := func() *Frame {
+		if ctx.Yanked.Container != nil && ctx.Yanked.Container.Root != nil {
+			if ctx.Yanked.Container == target.Container {
+				log.Println("tried to yank container into itself")
+				return nil
 			}
-			return nf
+			s := ctx.Yanked.Container.Root
+			s.Unmap(ctx)
+			ctx.Yanked.Container.Destroy(ctx)
+			return s
 		} else {
-			nf := existing
-			nf.Parent = ap
-			ap.ChildB = nf
-			nf.Traverse(func(ft *Frame) {
-				ft.Container = ap.Container
-			})
-			return nf
+			s := ctx.Get(ctx.Yanked.Window)
+			if s == target {
+				log.Println("tried to yank frame into itself")
+				return nil
+			}
+			if s != nil {
+				s.Orphan(ctx)
+			}
+			return s
 		}
 	}()
-	cb.Map()
+	if source == nil {
+		log.Println("could not find source window for yank attach")
+		return nil
+	}
 
-	ap.MoveResize(ctx)
-	ap.Container.Raise(ctx)
-	cb.Find(func(ff *Frame) bool { return ff.IsLeaf() }).Focus(ctx)
-	return cb
+	return AttachWindow(ctx, target, partition, 0, source)
 }
 
 func NewWindow(ctx *Context, window xproto.Window) *Frame {
@@ -74,17 +143,54 @@ func NewWindow(ctx *Context, window xproto.Window) *Frame {
 	if existing != nil && existing.Container != nil {
 		return existing
 	}
+	if existing == nil && IsDock(ctx, window) {
+		ManageDock(ctx, window)
+		return nil
+	}
+
+	f := func() *Frame {
+		// Dialogs float over their parent, they should never take the place of a split or layout slot
+		if floating, focus, parent := ShouldFloat(ctx, window); floating {
+			return NewFloatingWindow(ctx, window, existing, parent, focus)
+		}
 
-	if ctx.AttachPoint != nil {
-		defer func() { ctx.AttachPoint = nil }()
-		return AttachWindow(ctx, ctx.AttachPoint.Target, ctx.AttachPoint.Type, window, nil)
+		if existing == nil {
+			if f := PlaceLayoutWindow(ctx, window); f != nil {
+				return f
+			}
+		}
+
+		if ctx.AttachPoint != nil {
+			defer func() { ctx.AttachPoint = nil }()
+			ctx.RecordLayout()
+			return AttachWindow(ctx, ctx.AttachPoint.Target, ctx.AttachPoint.Type, window, nil)
+		}
+
+		return NewContainer(ctx, window, existing)
+	}()
+	if f != nil && !f.IsOrphan() {
+		ctx.UpdateClientList()
+		ctx.EmitWindow(ipc.EventManage, window)
 	}
+	return f
+}
 
-	// Create container and root frame
+// NewContainer wraps a window in a container of its own, reusing its frame if it already had one.
+func NewContainer(ctx *Context, window xproto.Window, existing *Frame) *Frame {
 	c := &Container{
 		Shape: ctx.DefaultShapeForScreen(ctx.LastFocusedScreen()),
 	}
+	return WrapWindow(ctx, c, window, existing, true)
+}
+
+// WrapWindow puts a window at the root of a new container that has its shape already set.
+func WrapWindow(ctx *Context, c *Container, window xproto.Window, existing *Frame, focus bool) *Frame {
+	c.Workspace = ctx.CurrentWorkspace(ctx.ScreenIndex(c))
+	if c.TransientFor != nil {
+		c.Workspace = c.TransientFor.Workspace
+	}
 
+	// Create root frame
 	root := func() *Frame {
 		if existing != nil {
 			existing.Container = c
@@ -110,20 +216,82 @@ func NewWindow(ctx *Context, window xproto.Window) *Frame {
 
 	c.Root = root
 
-	// Create window decorations and hook up callbacks
-	err := GeneratePieces(ctx, c)
-	ext.Logerr(err)
+	// Create window decorations and hook up callbacks, floating containers go without unless they are shown
+	if !c.Floating {
+		err := GeneratePieces(ctx, c)
+		ext.Logerr(err)
 
-	if err != nil {
-		log.Println("NewWindow: failed to create container")
-		return nil
+		if err != nil {
+			log.Println("NewWindow: failed to create container")
+			return nil
+		}
 	}
 
-	c.Map()
+	c.UpdateFrameMappings(ctx)
 	ctx.Tracked[window] = c.Root
 	ctx.Containers[c] = struct{}{}
 	ctx.Taskbar.UpdateContainer(ctx, c)
 	c.Raise(ctx)
-	c.Root.Focus(ctx)
+	if focus {
+		c.Root.Focus(ctx)
+	}
 	return c.Root
 }
+
+// AdoptWindows manages every client window that was already on screen before we started,
+// such as after a crash or an in place restart. Windows are put back into the saved layout
+// if there is one, otherwise they keep their current shape.
+func AdoptWindows(ctx *Context) {
+	tree, err := xproto.QueryTree(ctx.X.Conn(), ctx.X.RootWin()).Reply()
+	if err != nil {
+		log.Println("could not query existing windows:", err)
+		return
+	}
+
+	setup := ctx.X.Setup()
+	available := make(map[xproto.Window]bool)
+	for _, window := range tree.Children {
+		// Skip our own decorations, they share our resource id base
+		if uint32(window)&^setup.ResourceIdMask == setup.ResourceIdBase {
+			continue
+		}
+
+		attrs, err := xproto.GetWindowAttributes(ctx.X.Conn(), window).Reply()
+		if err != nil || attrs.OverrideRedirect || attrs.MapState != xproto.MapStateViewable {
+			continue
+		}
+		available[window] = true
+	}
+
+	// Put windows back into their saved layout first, whatever is left gets a container of its own
+	RestoreState(ctx, available)
+
+	for _, window := range tree.Children {
+		if !available[window] {
+			continue
+		}
+
+		geom, err := xwindow.New(ctx.X, window).Geometry()
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		f := NewWindow(ctx, window)
+		if f == nil || f.IsOrphan() {
+			continue
+		}
+
+		cShape := ContainerShapeFromRoot(ctx, Rect{X: geom.X(), Y: geom.Y(), W: geom.Width(), H: geom.Height()})
+		cShape.X = ext.IMax(cShape.X, 0)
+		cShape.Y = ext.IMax(cShape.Y, 0)
+		f.Container.MoveResizeShape(ctx, cShape)
+	}
+
+	// Windows keep their _NET_WM_STATE across restarts, so fullscreen and maximized windows go back to that
+	for _, window := range tree.Children {
+		if f := ctx.Get(window); f != nil {
+			ApplyRequestedWmState(ctx, f)
+		}
+	}
+}
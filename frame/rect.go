@@ -24,6 +24,11 @@ func (r *Rect) Area() int {
 	return r.W * r.H
 }
 
+// Contains checks if a point is inside the rectangle.
+func (r *Rect) Contains(x, y int) bool {
+	return x >= r.X && x < r.X+r.W && y >= r.Y && y < r.Y+r.H
+}
+
 func AreaOfIntersection(shapeA, shapeB Rect) int {
 	xminmax := ext.IMin(shapeA.X+shapeA.W, shapeB.X+shapeB.W)
 	xmaxmin := ext.IMax(shapeA.X, shapeB.X)
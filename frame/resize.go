@@ -0,0 +1,66 @@
+package frame
+
+import (
+	"github.com/levavakian/rowm/ext"
+)
+
+// ResizeSeparator returns the closest frame above f that is split along a direction, and which of its children f is in.
+// Only frames inside the active root are considered.
+func (f *Frame) ResizeSeparator(dir AnchorType) (*Frame, int) {
+	want := HORIZONTAL
+	if dir == TOP || dir == BOTTOM {
+		want = VERTICAL
+	}
+	if f.IsOrphan() {
+		return nil, -1
+	}
+	stop := f.Container.ActiveRoot()
+	for child, p := f, f.Parent; p != nil && child != stop; child, p = p, p.Parent {
+		if !p.IsTabbed() && p.Separator.Type == want {
+			return p, child.Index()
+		}
+	}
+	return nil, -1
+}
+
+// GrowFrame moves the closest separator along a direction by one step, growing the frame for RIGHT and BOTTOM
+// and shrinking it for LEFT and TOP. It returns the frame whose separator moved, nil if there was none.
+func (f *Frame) GrowFrame(ctx *Context, dir AnchorType) *Frame {
+	p, k := f.ResizeSeparator(dir)
+	if p == nil {
+		return nil
+	}
+
+	step := ctx.Config.ElemSize
+	if dir == LEFT || dir == TOP {
+		step = -step
+	}
+	// The last child has no separator after it, so the one before it moves the other way
+	if k == len(p.Children)-1 {
+		k--
+		step = -step
+	}
+	origin, _ := p.alongSplit(p.Shape)
+	start, size := p.alongSplit(p.ChildSlots(ctx, p.Shape)[k])
+	p.MoveSeparator(ctx, k, start+size-origin+step)
+	p.MoveResize(ctx)
+	return p
+}
+
+// GrowContainer resizes a container by ElemSize along a direction, growing it for RIGHT and BOTTOM
+// and shrinking it for LEFT and TOP, down to its minimum shape.
+func (c *Container) GrowContainer(ctx *Context, dir AnchorType) {
+	min := c.MinShape(ctx)
+	shape := c.Shape
+	switch dir {
+	case RIGHT:
+		shape.W += ctx.Config.ElemSize
+	case LEFT:
+		shape.W = ext.IMax(shape.W-ctx.Config.ElemSize, min.W)
+	case BOTTOM:
+		shape.H += ctx.Config.ElemSize
+	case TOP:
+		shape.H = ext.IMax(shape.H-ctx.Config.ElemSize, min.H)
+	}
+	c.MoveResize(ctx, shape.X, shape.Y, shape.W, shape.H)
+}
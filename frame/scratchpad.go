@@ -0,0 +1,83 @@
+package frame
+
+import (
+	"github.com/levavakian/rowm/ext"
+)
+
+// Scratchpads returns the containers in the scratchpad, next to be shown first,
+// forgetting any that have been closed since.
+func (ctx *Context) Scratchpads() []*Container {
+	kept := ctx.Scratchpad[:0]
+	for _, c := range ctx.Scratchpad {
+		if _, ok := ctx.Containers[c]; ok && c.Scratchpad && c.Root != nil {
+			kept = append(kept, c)
+		}
+	}
+	ctx.Scratchpad = kept
+	return kept
+}
+
+// MarkScratchpad moves a container into the scratchpad and hides it until the scratchpad is toggled.
+// Marking a container that is already in the scratchpad takes it back out.
+func (ctx *Context) MarkScratchpad(c *Container) {
+	if c.Scratchpad {
+		c.Scratchpad = false
+		ctx.Scratchpads()
+		if c.Hidden {
+			c.ChangeMinimizationState(ctx)
+		}
+		ctx.Taskbar.UpdateContainer(ctx, c)
+		return
+	}
+
+	c.Scratchpad = true
+	ctx.Scratchpad = append(ctx.Scratchpads(), c)
+	ctx.Taskbar.RemoveContainer(ctx, c)
+	if !c.Hidden {
+		screen := ctx.ScreenIndex(c)
+		c.ChangeMinimizationState(ctx)
+		ctx.FocusWorkspace(screen)
+	}
+}
+
+// ToggleScratchpad shows the next scratchpad container centered on the focused screen, above everything else.
+// If a scratchpad container is already focused it is hidden again, and goes to the back of the line.
+func (ctx *Context) ToggleScratchpad() {
+	scratchpads := ctx.Scratchpads()
+	if len(scratchpads) == 0 {
+		return
+	}
+
+	for i, c := range scratchpads {
+		if !c.Shown(ctx) {
+			continue
+		}
+		if ff := ctx.GetFocusedFrame(); ff == nil || ff.Container != c {
+			c.RaiseFindFocus(ctx)
+			return
+		}
+		screen := ctx.ScreenIndex(c)
+		c.ChangeMinimizationState(ctx)
+		ctx.Scratchpad = append(append(scratchpads[:i:i], scratchpads[i+1:]...), c)
+		ctx.FocusWorkspace(screen)
+		return
+	}
+
+	c := scratchpads[0]
+	screen := ctx.LastFocusedScreen()
+	area := ctx.WorkArea(screen)
+	shape := c.Shape
+	shape.W = ext.IMin(shape.W, area.W)
+	shape.H = ext.IMin(shape.H, area.H)
+	shape.X = area.X + (area.W-shape.W)/2
+	shape.Y = area.Y + (area.H-shape.H)/2
+	c.MoveResizeShape(ctx, shape)
+	// Scratchpad containers left open on another workspace are brought over as well
+	c.Workspace = ctx.CurrentWorkspace(ctx.ScreenIndex(c))
+	if c.Hidden {
+		c.ChangeMinimizationState(ctx)
+	} else {
+		c.UpdateFrameMappings(ctx)
+		c.RaiseFindFocus(ctx)
+	}
+}
@@ -0,0 +1,317 @@
+package frame
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/xprop"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FrameState is the saved form of a frame tree, leaves only keep their window.
+type FrameState struct {
+	Window   xproto.Window `json:",omitempty"`
+	Type     PartitionType
+	Weights  []float64     `json:",omitempty"`
+	Expanded bool          `json:",omitempty"`
+	Active   bool          `json:",omitempty"` // The tab shown by its tab group
+	Children []*FrameState `json:",omitempty"`
+}
+
+// ContainerState is the saved form of a container and its frame tree.
+type ContainerState struct {
+	Shape               Rect
+	LastUnanchoredShape Rect
+	Hidden              bool
+	DecorationsHidden   bool
+	Workspace           int
+	Scratchpad          bool
+	Root                *FrameState
+}
+
+// State is everything saved across restarts. Window ids only mean something
+// to the X server that handed them out, so the display and server session are saved as well.
+type State struct {
+	Display    string
+	Session    string
+	Workspaces []int
+	Containers []ContainerState
+}
+
+// SessionProperty is set on the root window with an id for the running X server. It outlives
+// in place restarts, but a new server on the same display starts without it.
+const SessionProperty = "_ROWM_SESSION"
+
+// Session returns the id of the running X server, making one up if it has none yet.
+func (ctx *Context) Session() string {
+	if s, err := xprop.PropValStr(xprop.GetProperty(ctx.X, ctx.X.RootWin(), SessionProperty)); err == nil && s != "" {
+		return s
+	}
+	s := strconv.FormatInt(time.Now().UnixNano(), 36)
+	ext.Logerr(xprop.ChangeProp(ctx.X, ctx.X.RootWin(), 8, SessionProperty, "STRING", []byte(s)))
+	return s
+}
+
+// StatePath is where the layout is saved to on shutdown or restart.
+// Each display gets its own file, so instances on other displays leave it alone.
+func StatePath() string {
+	display := strings.ReplaceAll(os.Getenv("DISPLAY"), "/", "_")
+	return path.Join(HomeDir(), ".cache/rowm", fmt.Sprintf("state-%s.json", display))
+}
+
+func (f *Frame) SaveState() *FrameState {
+	if f == nil {
+		return nil
+	}
+
+	fs := &FrameState{
+		Type:     f.Separator.Type,
+		Expanded: f.Container != nil && f.Container.Expanded == f,
+		Active:   f.TabActive,
+	}
+	if f.IsLeaf() && f.Window != nil {
+		fs.Window = f.Window.Id
+	}
+	if !f.IsLeaf() {
+		fs.Weights = f.weights()
+	}
+	for _, child := range f.Children {
+		fs.Children = append(fs.Children, child.SaveState())
+	}
+	return fs
+}
+
+func (c *Container) SaveState() ContainerState {
+	cs := ContainerState{
+		Shape:               c.Shape,
+		LastUnanchoredShape: c.LastUnanchoredShape,
+		Hidden:              c.Hidden,
+		DecorationsHidden:   c.Decorations.Hidden,
+		Workspace:           c.Workspace,
+		Scratchpad:          c.Scratchpad,
+		Root:                c.Root.SaveState(),
+	}
+	// Fullscreen and maximized windows are put back in that state when they are adopted, so save where they came from
+	if !c.WmState.IsNormal() {
+		cs.Shape = c.WmState.SavedShape
+		cs.DecorationsHidden = c.WmState.SavedDecorationsHidden
+	}
+	return cs
+}
+
+// SaveState writes the layout of every container to StatePath.
+func (ctx *Context) SaveState() error {
+	state := State{
+		Display:    os.Getenv("DISPLAY"),
+		Session:    ctx.Session(),
+		Workspaces: ctx.Workspaces,
+		Containers: make([]ContainerState, 0, len(ctx.Containers)),
+	}
+	for c := range ctx.Containers {
+		if c.Root == nil {
+			continue
+		}
+		state.Containers = append(state.Containers, c.SaveState())
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(path.Dir(StatePath()), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(StatePath(), data, 0600)
+}
+
+// TakeState reads the saved layout and removes it so it is only ever restored once.
+// Layouts saved by another X server session are ignored, their window ids belong to other windows now.
+func TakeState(session string) (*State, error) {
+	data, err := ioutil.ReadFile(StatePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		ext.Logerr(os.Remove(StatePath()))
+		return nil, err
+	}
+	if state.Display != os.Getenv("DISPLAY") {
+		return nil, nil
+	}
+	// Saves from an earlier session on this display are of no use to anyone anymore
+	ext.Logerr(os.Remove(StatePath()))
+	if state.Session != session {
+		return nil, nil
+	}
+	return state, nil
+}
+
+// Prune drops leaves whose windows are not available, collapsing any split left with a single child.
+func (fs *FrameState) Prune(available map[xproto.Window]bool) *FrameState {
+	if fs == nil {
+		return nil
+	}
+	if len(fs.Children) == 0 {
+		if !available[fs.Window] {
+			return nil
+		}
+		return fs
+	}
+
+	pruned := *fs
+	pruned.Children = nil
+	pruned.Weights = nil
+	for i, child := range fs.Children {
+		child = child.Prune(available)
+		if child == nil {
+			continue
+		}
+		weight := 1.0
+		if len(fs.Weights) == len(fs.Children) {
+			weight = fs.Weights[i]
+		}
+		pruned.Children = append(pruned.Children, child)
+		pruned.Weights = append(pruned.Weights, weight)
+	}
+	switch len(pruned.Children) {
+	case 0:
+		return nil
+	case 1:
+		return pruned.Children[0]
+	}
+	return &pruned
+}
+
+func (fs *FrameState) FirstWindow() xproto.Window {
+	if len(fs.Children) > 0 {
+		return fs.Children[0].FirstWindow()
+	}
+	return fs.Window
+}
+
+// BuildTree replaces the frame tree of a container with a saved one, leaf gives the frame to use for each window.
+func (c *Container) BuildTree(ctx *Context, fs *FrameState, leaf func(w xproto.Window) *Frame) {
+	c.Expanded = nil
+	var build func(fs *FrameState, parent *Frame) *Frame
+	build = func(fs *FrameState, parent *Frame) *Frame {
+		f := &Frame{}
+		if len(fs.Children) == 0 {
+			f = leaf(fs.Window)
+		}
+		f.Container = c
+		f.Parent = parent
+		if fs.Expanded {
+			c.Expanded = f
+		}
+		f.TabActive = fs.Active
+		if len(fs.Children) > 0 {
+			f.Separator.Type = fs.Type
+			f.Separator.Weights = fs.Weights
+			for _, child := range fs.Children {
+				f.Children = append(f.Children, build(child, f))
+			}
+			f.NormalizeWeights()
+			// Shapes are calculated once the whole tree is in place
+			f.UpdateDecorations(ctx)
+		}
+		return f
+	}
+	c.Root = build(fs, nil)
+	c.UpdateTabs(ctx)
+}
+
+// RestoreContainer rebuilds a saved container out of the available windows, which are marked as used.
+func RestoreContainer(ctx *Context, cs ContainerState, available map[xproto.Window]bool) *Container {
+	fs := cs.Root.Prune(available)
+	if fs == nil {
+		return nil
+	}
+
+	// Start with a plain container for the first window, then swap in the saved tree
+	first := NewWindow(ctx, fs.FirstWindow())
+	if first == nil || first.IsOrphan() {
+		return nil
+	}
+	c := first.Container
+	c.Decorations.Hidden = cs.DecorationsHidden
+	c.Shape = cs.Shape
+
+	c.BuildTree(ctx, fs, func(w xproto.Window) *Frame {
+		available[w] = false
+		if w == first.Window.Id {
+			return first
+		}
+		nf := &Frame{Window: xwindow.New(ctx.X, w)}
+		ctx.Tracked[w] = nf
+		ext.Logerr(AddWindowHook(ctx, w))
+		ctx.EmitWindow(ipc.EventManage, w)
+		return nf
+	})
+
+	c.ApplyState(ctx, cs)
+	c.ApplyVisibility(ctx, cs)
+	return c
+}
+
+// ApplyState puts the shape, decorations and workspace of a container, whose tree is already in place, back the way
+// they were saved. Fullscreen and maximized containers keep covering what they cover, and go back to the saved
+// shape and decorations once they are restored.
+func (c *Container) ApplyState(ctx *Context, cs ContainerState) {
+	shape := cs.Shape
+	if c.WmState.IsNormal() {
+		c.Decorations.Hidden = cs.DecorationsHidden
+	} else {
+		c.WmState.SavedShape = cs.Shape
+		c.WmState.SavedDecorationsHidden = cs.DecorationsHidden
+		shape = c.Shape
+	}
+	c.LastUnanchoredShape = cs.LastUnanchoredShape
+	c.MoveResizeShape(ctx, shape)
+	c.Workspace = ext.IClamp(cs.Workspace, 0, ctx.NumWorkspaces()-1)
+	c.UpdateFrameMappings(ctx)
+	ctx.Taskbar.UpdateContainer(ctx, c)
+}
+
+// ApplyVisibility puts a newly made container back in the scratchpad or minimizes it, if it was saved that way.
+func (c *Container) ApplyVisibility(ctx *Context, cs ContainerState) {
+	if cs.Scratchpad {
+		ctx.MarkScratchpad(c)
+	} else if cs.Hidden {
+		c.ChangeMinimizationState(ctx)
+	}
+}
+
+// RestoreState rebuilds every saved container it can out of the available windows.
+func RestoreState(ctx *Context, available map[xproto.Window]bool) {
+	state, err := TakeState(ctx.Session())
+	if err != nil {
+		log.Println("could not restore layout:", err)
+		return
+	}
+	if state == nil {
+		return
+	}
+
+	for i, w := range state.Workspaces {
+		ctx.CurrentWorkspace(i)
+		ctx.Workspaces[i] = ext.IClamp(w, 0, ctx.NumWorkspaces()-1)
+	}
+	for _, cs := range state.Containers {
+		RestoreContainer(ctx, cs, available)
+	}
+	ctx.UpdateWorkspaceHints()
+}
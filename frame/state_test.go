@@ -0,0 +1,71 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"testing"
+)
+
+func leafState(w xproto.Window) *FrameState {
+	return &FrameState{Window: w}
+}
+
+func windows(fs *FrameState) []xproto.Window {
+	if fs == nil {
+		return nil
+	}
+	if len(fs.Children) == 0 {
+		return []xproto.Window{fs.Window}
+	}
+	ws := make([]xproto.Window, 0)
+	for _, child := range fs.Children {
+		ws = append(ws, windows(child)...)
+	}
+	return ws
+}
+
+func TestPrune(t *testing.T) {
+	tree := func() *FrameState {
+		return &FrameState{
+			Type:    HORIZONTAL,
+			Weights: []float64{.2, .3, .5},
+			Children: []*FrameState{
+				leafState(1),
+				{Type: VERTICAL, Weights: []float64{.4, .6}, Children: []*FrameState{leafState(2), leafState(3)}},
+				leafState(4),
+			},
+		}
+	}
+	available := func(ws ...xproto.Window) map[xproto.Window]bool {
+		m := make(map[xproto.Window]bool)
+		for _, w := range ws {
+			m[w] = true
+		}
+		return m
+	}
+
+	pruned := tree().Prune(available(1, 2, 3, 4))
+	if got := windows(pruned); len(got) != 4 || !closeTo(pruned.Weights, []float64{.2, .3, .5}) {
+		t.Errorf("nothing missing: got %v with weights %v", got, pruned.Weights)
+	}
+
+	pruned = tree().Prune(available(1, 2, 3))
+	if got := windows(pruned); len(got) != 3 || !closeTo(pruned.Weights, []float64{.2, .3}) {
+		t.Errorf("last leaf missing: got %v with weights %v", got, pruned.Weights)
+	}
+
+	// A split left with one child is replaced by it
+	pruned = tree().Prune(available(1, 3, 4))
+	if len(pruned.Children) != 3 || pruned.Children[1].Window != 3 || len(pruned.Children[1].Children) != 0 {
+		t.Errorf("nested split was not collapsed: got %v", windows(pruned))
+	}
+
+	pruned = tree().Prune(available(4))
+	if pruned == nil || pruned.Window != 4 || len(pruned.Children) != 0 {
+		t.Errorf("single window left: got %v", windows(pruned))
+	}
+
+	if pruned = tree().Prune(available()); pruned != nil {
+		t.Errorf("no windows left: got %v", windows(pruned))
+	}
+
+}
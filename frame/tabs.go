@@ -0,0 +1,225 @@
+package frame
+
+import (
+	"github.com/BurntSushi/wingo/prompt"
+	"github.com/BurntSushi/wingo/render"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/mousebind"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/BurntSushi/xgbutil/xgraphics"
+	"github.com/levavakian/rowm/ext"
+	"image"
+)
+
+// A tab group is a TABBED frame, each of its children is a tab. All tabs share the shape under the
+// tab strip of the group, and only the active tab is mapped.
+
+// IsTabbed checks if a frame splits its children into tabs.
+func (f *Frame) IsTabbed() bool {
+	return !f.IsLeaf() && f.Separator.Type == TABBED
+}
+
+// TabGroup returns the tab group a frame is directly part of, nil if it isn't in one.
+func (f *Frame) TabGroup() *Frame {
+	if f.Parent == nil || !f.Parent.IsTabbed() {
+		return nil
+	}
+	return f.Parent
+}
+
+// IsTab checks if a frame is one of the tabs of a tab group.
+func (f *Frame) IsTab() bool {
+	return f.TabGroup() != nil
+}
+
+// Tabs lists the tabs of a TABBED frame in order.
+func (f *Frame) Tabs() []*Frame {
+	if !f.IsTabbed() {
+		return []*Frame{f}
+	}
+	return f.Children
+}
+
+// ActiveTab returns the tab shown by a tab group, the first one if none has been picked yet.
+// It only looks, UpdateTabs is what marks the picked tab as active.
+func (f *Frame) ActiveTab() *Frame {
+	tabs := f.Tabs()
+	for _, t := range tabs {
+		if t.TabActive {
+			return t
+		}
+	}
+	return tabs[0]
+}
+
+// TabHidden checks if a frame is inside a tab that isn't being shown.
+func (f *Frame) TabHidden() bool {
+	for t := f; t != nil; t = t.Parent {
+		if t.IsTab() && t.TabGroup().ActiveTab() != t {
+			return true
+		}
+	}
+	return false
+}
+
+// ShowTab makes a tab the one shown by its group.
+func (f *Frame) ShowTab(ctx *Context) {
+	g := f.TabGroup()
+	if g == nil || !f.IsTab() || g.ActiveTab() == f {
+		return
+	}
+	for _, t := range g.Tabs() {
+		t.TabActive = t == f
+		if t != f {
+			t.Unmap(ctx)
+		}
+	}
+	g.MoveResize(ctx)
+	g.DrawTabStrip(ctx)
+}
+
+// RevealTabs shows every tab a frame is in, so it is visible.
+func (f *Frame) RevealTabs(ctx *Context) {
+	for t := f; t != nil; t = t.Parent {
+		if t.IsTab() {
+			t.ShowTab(ctx)
+		}
+	}
+}
+
+// NextTab returns the tab after (or before) the one a frame is in, wrapping around its group.
+func (f *Frame) NextTab(reverse bool) *Frame {
+	t := f
+	for t != nil && !t.IsTab() {
+		t = t.Parent
+	}
+	if t == nil {
+		return nil
+	}
+	tabs := t.TabGroup().Tabs()
+	for i, tab := range tabs {
+		if tab != t {
+			continue
+		}
+		if reverse {
+			return tabs[(i+len(tabs)-1)%len(tabs)]
+		}
+		return tabs[(i+1)%len(tabs)]
+	}
+	return nil
+}
+
+// TabContentShape is the space left to the tabs of a group with the given shape under its tab strip.
+func (f *Frame) TabContentShape(ctx *Context, shape Rect) Rect {
+	h := ext.IMin(ctx.Config.TabHeight, shape.H)
+	return Rect{
+		X: shape.X,
+		Y: shape.Y + h,
+		W: shape.W,
+		H: shape.H - h,
+	}
+}
+
+// TabStripShape is the shape of the tab strip along the top of a tab group.
+func (f *Frame) TabStripShape(ctx *Context) Rect {
+	return Rect{
+		X: f.Shape.X,
+		Y: f.Shape.Y,
+		W: f.Shape.W,
+		H: ext.IMin(ctx.Config.TabHeight, f.Shape.H),
+	}
+}
+
+// UpdateTabs makes sure every tab group in the container has exactly one active tab, and redraws their strips.
+func (c *Container) UpdateTabs(ctx *Context) {
+	if c.Root == nil {
+		return
+	}
+	c.Root.Traverse(func(f *Frame) {
+		if !f.IsTabbed() {
+			return
+		}
+		// Groups that were merged together can have more than one active tab, keep the first,
+		// and new groups have none yet, so pick the first tab
+		active := f.ActiveTab()
+		for _, t := range f.Tabs() {
+			t.TabActive = t == active
+		}
+		f.DrawTabStrip(ctx)
+	})
+}
+
+// CreateTabStripDecoration creates the tab strip of a tab group, clicking on a tab focuses it.
+func (f *Frame) CreateTabStripDecoration(ctx *Context) (Decoration, error) {
+	d, err := CreateDecoration(ctx, Rect{X: 0, Y: 0, W: 1, H: 1}, ctx.Config.TabInactiveColor, 0)
+	if err != nil {
+		return d, err
+	}
+
+	err = mousebind.ButtonPressFun(
+		func(X *xgbutil.XUtil, ev xevent.ButtonPressEvent) {
+			if ctx.Locked || f.IsOrphan() || !f.IsTabbed() || f.Shape.W <= 0 {
+				return
+			}
+			tabs := f.Tabs()
+			idx := ext.IClamp(int(ev.EventX)*len(tabs)/f.Shape.W, 0, len(tabs)-1)
+			tabs[idx].FocusRaise(ctx)
+		}).Connect(ctx.X, d.Window.Id, ctx.Config.ButtonClick, false, true)
+	ext.Logerr(err)
+	return d, nil
+}
+
+// DrawTabStrip draws the icon and title of every tab in a group, highlighting the active one.
+func (f *Frame) DrawTabStrip(ctx *Context) {
+	if !f.IsTabbed() || len(f.Separator.Decorations) == 0 {
+		return
+	}
+	strip := f.Separator.Decorations[0].Window
+	s := f.TabStripShape(ctx)
+	if s.W <= 0 || s.H <= 0 {
+		return
+	}
+
+	font := prompt.DefaultInputTheme.Font
+	img := xgraphics.New(ctx.X, image.Rect(0, 0, s.W, s.H))
+	defer img.Destroy()
+
+	tabs := f.Tabs()
+	active := f.ActiveTab()
+	isize := ext.IMax(s.H-4, 1)
+	for i, t := range tabs {
+		x0, x1 := i*s.W/len(tabs), (i+1)*s.W/len(tabs)
+		tab, ok := img.SubImage(image.Rect(x0, 0, x1, s.H)).(*xgraphics.Image)
+		if !ok || tab == nil {
+			continue
+		}
+		color := ctx.Config.TabInactiveColor
+		if t == active {
+			color = ctx.Config.TabActiveColor
+		}
+		xgraphics.BlendBgColor(tab, render.NewColor(int(color)).ImageColor())
+		if border, ok := img.SubImage(image.Rect(x1-1, 0, x1, s.H)).(*xgraphics.Image); ok && border != nil && i < len(tabs)-1 {
+			xgraphics.BlendBgColor(border, render.NewColor(int(ctx.Config.SeparatorColor)).ImageColor())
+		}
+
+		leaf := t.Find(func(ff *Frame) bool { return ff.IsLeaf() })
+		if leaf == nil || leaf.Window == nil {
+			continue
+		}
+		tx := x0 + 2
+		if icon, err := xgraphics.FindIcon(ctx.X, leaf.Window.Id, isize, isize); err == nil {
+			if dst, ok := img.SubImage(image.Rect(x0+2, 2, x0+2+isize, 2+isize)).(*xgraphics.Image); ok && dst != nil {
+				xgraphics.Blend(dst, icon, icon.Bounds().Min)
+			}
+			tx += isize + 2
+		}
+		_, eh := xgraphics.Extents(font, ctx.Config.TaskbarFontSize, "A")
+		title := ext.WindowTitle(ctx.X, leaf.Window.Id)
+		_, _, err := tab.Text(tx, (s.H-eh)/2, render.NewColor(int(ctx.Config.TaskbarTextColor)).ImageColor(), ctx.Config.TaskbarFontSize, font, title)
+		ext.Logerr(err)
+	}
+
+	img.XSurfaceSet(strip.Id)
+	img.XDraw()
+	img.XPaint(strip.Id)
+}
@@ -13,7 +13,9 @@ import (
 	"github.com/BurntSushi/xgbutil/xwindow"
 	"github.com/distatus/battery"
 	"github.com/levavakian/rowm/ext"
+	"image"
 	"log"
+	"strconv"
 	"time"
 )
 
@@ -23,12 +25,13 @@ type History struct {
 }
 
 type Taskbar struct {
-	Base     Decoration
-	TimeWin  *xwindow.Window
-	BatWin   *xwindow.Window
-	Hidden   bool
-	Scroller *ElementScroller
-	History  History
+	Base       Decoration
+	TimeWin    *xwindow.Window
+	BatWin     *xwindow.Window
+	Hidden     bool
+	Scroller   *ElementScroller
+	History    History
+	Workspaces []*xwindow.Window // Buttons for switching to each workspace
 }
 
 type Element struct {
@@ -90,6 +93,9 @@ func NewTaskbar(ctx *Context) *Taskbar {
 	// Scroller
 	t.Scroller = NewElementScroller(ctx)
 
+	// Workspace buttons
+	t.UpdateWorkspaces(ctx)
+
 	// Initial render
 	t.Update(ctx)
 	return t
@@ -111,16 +117,15 @@ func (t *Taskbar) UpdateContainer(ctx *Context, c *Container) {
 		log.Println("wanted to update childless container")
 		return
 	}
+	if c.Floating || c.Scratchpad {
+		t.RemoveContainer(ctx, c)
+		return
+	}
 
 	elem, ok := t.Scroller.Elements[c]
 	if !ok {
-		active := ShouldActivate(len(t.Scroller.Elements), t.Scroller.StartingIdx, t.Scroller.CanFit)
-		offset := 0
-		if active {
-			offset = len(t.Scroller.Elements) - t.Scroller.StartingIdx
-		}
-		elem = NewElement(ctx, c, offset)
-		elem.Active = active
+		// Placed and activated along with the others below, since it may be on a workspace that isn't shown
+		elem = NewElement(ctx, c, 0)
 		t.Scroller.Elements[c] = elem
 		if t.Scroller.Front == nil {
 			t.Scroller.Front = elem
@@ -151,7 +156,7 @@ func (t *Taskbar) UpdateContainer(ctx *Context, c *Container) {
 	ximg.XPaint(elem.Window.Id)
 
 	elem.UpdateMapping(ctx)
-	t.Scroller.UpdateMappings(ctx)
+	t.Scroller.ShiftAndActivate(ctx)
 }
 
 func (t *Taskbar) RemoveContainer(ctx *Context, c *Container) {
@@ -250,20 +255,38 @@ func (es *ElementScroller) ForEach(f func(*Element, int)) {
 	}
 }
 
+// NumListed counts the elements that are listed on the taskbar, the ones for containers on shown workspaces.
+func (es *ElementScroller) NumListed(ctx *Context) int {
+	count := 0
+	es.ForEach(func(e *Element, idx int) {
+		if e.Container.OnCurrentWorkspace(ctx) {
+			count++
+		}
+	})
+	return count
+}
+
 func (es *ElementScroller) ShiftAndActivate(ctx *Context) {
-	if len(es.Elements) < (es.StartingIdx + es.CanFit) {
-		es.StartingIdx = ext.IMax(len(es.Elements)-es.CanFit, 0)
+	listed := es.NumListed(ctx)
+	if listed < (es.StartingIdx + es.CanFit) {
+		es.StartingIdx = ext.IMax(listed-es.CanFit, 0)
 	}
 
-	es.ForEach(func(e *Element, idx int) {
-		active := ShouldActivate(idx, es.StartingIdx, es.CanFit)
+	// Elements for containers on workspaces that aren't shown are skipped over
+	idx := 0
+	es.ForEach(func(e *Element, _ int) {
+		active := false
+		if e.Container.OnCurrentWorkspace(ctx) {
+			active = ShouldActivate(idx, es.StartingIdx, es.CanFit)
+			if active {
+				e.MoveResize(ctx, idx)
+			}
+			idx++
+		}
 		if !active && !e.Active {
 			return
 		}
 		e.Active = active
-		if e.Active {
-			e.MoveResize(ctx, idx)
-		}
 		e.UpdateMapping(ctx)
 	})
 
@@ -288,7 +311,7 @@ func (es *ElementScroller) UpdateMappings(ctx *Context) {
 		es.ShiftLeftInactive.Map()
 		es.ShiftLeftActive.Unmap()
 	}
-	if len(es.Elements) > (es.StartingIdx + es.CanFit) {
+	if es.NumListed(ctx) > (es.StartingIdx + es.CanFit) {
 		es.ShiftRightInactive.Unmap()
 		es.ShiftRightActive.Map()
 	} else {
@@ -423,10 +446,11 @@ func (es *ElementScroller) SlideRight(ctx *Context) {
 		return
 	}
 
-	if (es.StartingIdx + es.CanFit) > len(es.Elements) {
+	listed := es.NumListed(ctx)
+	if (es.StartingIdx + es.CanFit) > listed {
 		return
 	}
-	es.StartingIdx = ext.IMin(es.StartingIdx+1, ext.IMax(len(es.Elements)-1, 0))
+	es.StartingIdx = ext.IMin(es.StartingIdx+1, ext.IMax(listed-1, 0))
 	es.UpdateMappings(ctx)
 	es.ShiftAndActivate(ctx)
 }
@@ -452,7 +476,7 @@ func (es *ElementScroller) AddSlideHooks(ctx *Context) error {
 func LeftSelectorShape(ctx *Context) Rect {
 	tshape := TaskbarShape(ctx)
 	return Rect{
-		X: tshape.X,
+		X: tshape.X + WorkspacesWidth(ctx),
 		Y: tshape.Y,
 		W: ctx.Config.TaskbarSlideWidth,
 		H: ctx.Config.TaskbarHeight,
@@ -486,6 +510,7 @@ func (t *Taskbar) MoveResize(ctx *Context) {
 	sb := BatShape(ctx)
 	t.BatWin.MoveResize(sb.X, sb.Y, sb.W, sb.H)
 	t.Scroller.MoveResize(ctx)
+	t.UpdateWorkspaces(ctx)
 }
 
 func (t *Taskbar) Update(ctx *Context) {
@@ -550,13 +575,42 @@ func (t *Taskbar) UpdateMapping(ctx *Context) {
 		t.TimeWin.Map()
 		t.BatWin.Map()
 	}
+	for _, w := range t.Workspaces {
+		if t.Hidden {
+			w.Unmap()
+		} else {
+			w.Map()
+		}
+	}
 	t.Scroller.UpdateMappings(ctx)
 }
 
+// UpdateColors repaints the taskbar pieces from the config, text is repainted on the next Update.
+func (t *Taskbar) UpdateColors(ctx *Context) {
+	t.Base.SetColor(ctx.Config.TaskbarBaseColor)
+	es := t.Scroller
+	for _, w := range []*xwindow.Window{es.ShiftLeftInactive, es.ShiftRightInactive} {
+		w.Change(xproto.CwBackPixel, ctx.Config.TaskbarSlideInactiveColor)
+		w.ClearAll()
+	}
+	for _, w := range []*xwindow.Window{es.ShiftLeftActive, es.ShiftRightActive} {
+		w.Change(xproto.CwBackPixel, ctx.Config.TaskbarSlideActiveColor)
+		w.ClearAll()
+	}
+	es.ForEach(func(e *Element, idx int) {
+		e.MinWin.Change(xproto.CwBackPixel, ctx.Config.TaskbarMinMaxColor)
+		e.MinWin.ClearAll()
+	})
+	t.UpdateWorkspaces(ctx)
+}
+
 func (t *Taskbar) Raise(ctx *Context) {
 	t.Base.Window.Stack(xproto.StackModeAbove)
 	t.TimeWin.Stack(xproto.StackModeAbove)
 	t.BatWin.Stack(xproto.StackModeAbove)
+	for _, w := range t.Workspaces {
+		w.Stack(xproto.StackModeAbove)
+	}
 	t.Scroller.Raise(ctx)
 }
 
@@ -564,6 +618,9 @@ func (t *Taskbar) Lower(ctx *Context) {
 	t.Base.Window.Stack(xproto.StackModeBelow)
 	t.TimeWin.Stack(xproto.StackModeBelow)
 	t.BatWin.Stack(xproto.StackModeBelow)
+	for _, w := range t.Workspaces {
+		w.Stack(xproto.StackModeBelow)
+	}
 	t.Scroller.Lower(ctx)
 }
 
@@ -573,12 +630,12 @@ func CalcCanFit(ctx *Context) int {
 	selectorwidth := ctx.Config.TaskbarSlideWidth
 	bs := BarrierElementShape(ctx)
 	rightpad := tshape.W - (bs.X - tshape.X) - ctx.Config.TaskbarXPad
-	return (tshape.W - selectorwidth*2 - rightpad) / iconwidth
+	return (tshape.W - WorkspacesWidth(ctx) - selectorwidth*2 - rightpad) / iconwidth
 }
 
 func ElementShape(ctx *Context, indexOffset int) Rect {
 	tshape := TaskbarShape(ctx)
-	startX := tshape.X
+	startX := tshape.X + WorkspacesWidth(ctx)
 	startX = startX + ctx.Config.TaskbarSlideWidth
 	increment := ctx.Config.TaskbarElementShape.X*2 + ctx.Config.TaskbarElementShape.W
 	startX = startX + increment*indexOffset
@@ -632,3 +689,101 @@ func BatShape(ctx *Context) Rect {
 		H: eh,
 	}
 }
+
+// UpdateWorkspaces draws a button for every workspace of the last focused screen,
+// highlighting the one that is shown and ones with windows that want attention.
+func (t *Taskbar) UpdateWorkspaces(ctx *Context) {
+	// Urgent windows can show up before the taskbar does
+	if t == nil {
+		return
+	}
+
+	count := ctx.NumWorkspaces()
+	if count == 1 {
+		count = 0
+	}
+	for len(t.Workspaces) > count {
+		last := len(t.Workspaces) - 1
+		t.Workspaces[last].Destroy()
+		t.Workspaces = t.Workspaces[:last]
+	}
+	for len(t.Workspaces) < count {
+		workspace := len(t.Workspaces)
+		s := WorkspaceButtonShape(ctx, workspace)
+		win, err := xwindow.Generate(ctx.X)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		win.Create(ctx.X.RootWin(), s.X, s.Y, s.W, s.H, 0)
+		err = mousebind.ButtonPressFun(func(X *xgbutil.XUtil, ev xevent.ButtonPressEvent) {
+			if ctx.Locked {
+				return
+			}
+			ctx.SwitchWorkspace(workspace)
+		}).Connect(ctx.X, win.Id, ctx.Config.ButtonClick, false, true)
+		ext.Logerr(err)
+		t.Workspaces = append(t.Workspaces, win)
+	}
+
+	ctx.LastFocusedScreen()
+	screen := ctx.LastKnownFocusedScreen
+	current := ctx.CurrentWorkspace(screen)
+	urgent := ctx.UrgentWorkspaces(screen)
+	for i, win := range t.Workspaces {
+		s := WorkspaceButtonShape(ctx, i)
+		win.MoveResize(s.X, s.Y, s.W, s.H)
+		color := ctx.Config.TaskbarWorkspaceColor
+		if i == current {
+			color = ctx.Config.TaskbarWorkspaceActiveColor
+		} else if urgent[i] {
+			color = ctx.Config.TaskbarWorkspaceUrgentColor
+		}
+		ext.Logerr(DrawLabel(ctx, win, s, color, strconv.Itoa(i+1)))
+		if t.Hidden {
+			win.Unmap()
+		} else {
+			win.Map()
+		}
+	}
+}
+
+// DrawLabel paints text centered over a solid background that covers the whole window.
+func DrawLabel(ctx *Context, win *xwindow.Window, shape Rect, bg uint32, label string) error {
+	font := prompt.DefaultInputTheme.Font
+	img := xgraphics.New(ctx.X, image.Rect(0, 0, shape.W, shape.H))
+	defer img.Destroy()
+	xgraphics.BlendBgColor(img, render.NewColor(int(bg)).ImageColor())
+
+	ew, eh := xgraphics.Extents(font, ctx.Config.TaskbarFontSize, label)
+	_, _, err := img.Text((shape.W-ew)/2, (shape.H-eh)/2, render.NewColor(int(ctx.Config.TaskbarTextColor)).ImageColor(), ctx.Config.TaskbarFontSize, font, label)
+	if err != nil {
+		return err
+	}
+	img.XSurfaceSet(win.Id)
+	img.XDraw()
+	img.XPaint(win.Id)
+	return nil
+}
+
+// WorkspaceButtonShape returns the shape of the button for a workspace, the buttons are at the very left of the taskbar.
+func WorkspaceButtonShape(ctx *Context, idx int) Rect {
+	tshape := TaskbarShape(ctx)
+	ew, _ := xgraphics.Extents(prompt.DefaultInputTheme.Font, ctx.Config.TaskbarFontSize, "00")
+	w := ew + 2*ctx.Config.TaskbarXPad
+	return Rect{
+		X: tshape.X + idx*w,
+		Y: tshape.Y,
+		W: w,
+		H: tshape.H,
+	}
+}
+
+// WorkspacesWidth is how much of the taskbar the workspace buttons take up, there are none with a single workspace.
+func WorkspacesWidth(ctx *Context) int {
+	count := ctx.NumWorkspaces()
+	if count == 1 {
+		return 0
+	}
+	return count * WorkspaceButtonShape(ctx, 0).W
+}
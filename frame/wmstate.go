@@ -0,0 +1,243 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/BurntSushi/xgbutil/xprop"
+	"github.com/levavakian/rowm/ext"
+	"log"
+)
+
+// The _NET_WM_STATE actions a client can send.
+const (
+	wmStateRemove = 0
+	wmStateAdd    = 1
+	wmStateToggle = 2
+)
+
+// wmStates are the _NET_WM_STATE values we manage, any others set by the client are left alone.
+var wmStates = []string{
+	"_NET_WM_STATE_FULLSCREEN",
+	"_NET_WM_STATE_MAXIMIZED_VERT",
+	"_NET_WM_STATE_MAXIMIZED_HORZ",
+	"_NET_WM_STATE_ABOVE",
+	"_NET_WM_STATE_HIDDEN",
+	"_NET_WM_STATE_DEMANDS_ATTENTION",
+}
+
+func init() {
+	SupportedHints = append(SupportedHints, "_NET_WM_STATE")
+	SupportedHints = append(SupportedHints, wmStates...)
+}
+
+// WindowState is the EWMH state clients can ask their container to be in.
+// The shape and decorations from before going fullscreen or maximized are kept so they can be restored exactly.
+type WindowState struct {
+	Fullscreen             bool
+	MaximizedVert          bool
+	MaximizedHorz          bool
+	Above                  bool
+	SavedShape             Rect
+	SavedDecorationsHidden bool
+	SavedExpanded          *Frame
+}
+
+// IsNormal is true when the container is neither fullscreen nor maximized.
+func (s *WindowState) IsNormal() bool {
+	return !s.Fullscreen && !s.MaximizedVert && !s.MaximizedHorz
+}
+
+// SetWmState switches the container into a new state, resizing it to match.
+// Fullscreen covers the whole monitor (including the taskbar) with only the given frame showing.
+func (c *Container) SetWmState(ctx *Context, f *Frame, state WindowState) {
+	if c.WmState.IsNormal() {
+		c.WmState.SavedShape = c.Shape
+		c.WmState.SavedDecorationsHidden = c.Decorations.Hidden
+		c.WmState.SavedExpanded = c.Expanded
+	}
+	wasFullscreen := c.WmState.Fullscreen
+	state.SavedShape = c.WmState.SavedShape
+	state.SavedDecorationsHidden = c.WmState.SavedDecorationsHidden
+	state.SavedExpanded = c.WmState.SavedExpanded
+	c.WmState = state
+
+	screen, _, _ := ctx.GetScreenForShape(c.Shape)
+	shape := c.WmState.SavedShape
+	if c.WmState.Fullscreen {
+		c.Decorations.Hidden = true
+		if f != nil && !f.IsRoot() {
+			c.Expanded = f
+		}
+		shape = screen
+	} else {
+		c.Decorations.Hidden = c.WmState.SavedDecorationsHidden
+		if wasFullscreen {
+			// The saved frame may have been closed while fullscreen
+			saved := c.WmState.SavedExpanded
+			c.Expanded = c.Root.Find(func(ff *Frame) bool { return ff == saved })
+		}
+		shape = c.MaximizedShape(ctx, screen, shape)
+	}
+
+	c.UpdateFrameMappings(ctx)
+	c.MoveResizeShape(ctx, shape)
+	c.Raise(ctx)
+	c.UpdateWmState(ctx)
+}
+
+// MaximizedShape stretches a shape across the work area of a screen in the directions the container is maximized in.
+func (c *Container) MaximizedShape(ctx *Context, screen Rect, shape Rect) Rect {
+	full := AnchorShape(ctx, screen, FULL)
+	if c.WmState.MaximizedVert {
+		shape.Y, shape.H = full.Y, full.H
+	}
+	if c.WmState.MaximizedHorz {
+		shape.X, shape.W = full.X, full.W
+	}
+	return shape
+}
+
+// UpdateWmState publishes the container's state in _NET_WM_STATE of each of its windows.
+func (c *Container) UpdateWmState(ctx *Context) {
+	if c.Root == nil {
+		return
+	}
+
+	fullscreen := make(map[*Frame]bool)
+	if c.WmState.Fullscreen {
+		c.ActiveRoot().Traverse(func(f *Frame) { fullscreen[f] = true })
+	}
+
+	c.Root.Traverse(func(f *Frame) {
+		if !f.IsLeaf() || f.Window == nil {
+			return
+		}
+
+		current, _ := ewmh.WmStateGet(ctx.X, f.Window.Id)
+		states := make([]string, 0, len(current))
+		for _, s := range current {
+			if !isManagedWmState(s) {
+				states = append(states, s)
+			}
+		}
+		if fullscreen[f] {
+			states = append(states, "_NET_WM_STATE_FULLSCREEN")
+		}
+		if c.WmState.MaximizedVert {
+			states = append(states, "_NET_WM_STATE_MAXIMIZED_VERT")
+		}
+		if c.WmState.MaximizedHorz {
+			states = append(states, "_NET_WM_STATE_MAXIMIZED_HORZ")
+		}
+		if c.WmState.Above {
+			states = append(states, "_NET_WM_STATE_ABOVE")
+		}
+		if c.Hidden {
+			states = append(states, "_NET_WM_STATE_HIDDEN")
+		}
+		if f.Urgent {
+			states = append(states, "_NET_WM_STATE_DEMANDS_ATTENTION")
+		}
+		ext.Logerr(ewmh.WmStateSet(ctx.X, f.Window.Id, states))
+	})
+}
+
+func isManagedWmState(s string) bool {
+	for _, m := range wmStates {
+		if s == m {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleWmStateMessage applies a _NET_WM_STATE client message to the container of the sending frame.
+func HandleWmStateMessage(ctx *Context, f *Frame, ev xevent.ClientMessageEvent) {
+	if f == nil || f.IsOrphan() {
+		return
+	}
+	c := f.Container
+
+	data := ev.Data.Data32
+	action := data[0]
+	apply := func(current bool) bool {
+		switch action {
+		case wmStateRemove:
+			return false
+		case wmStateAdd:
+			return true
+		case wmStateToggle:
+			return !current
+		}
+		return current
+	}
+
+	state := c.WmState
+	hidden := c.Hidden
+	urgent := f.Urgent
+	for _, atom := range data[1:3] {
+		if atom == 0 {
+			continue
+		}
+		name, err := xprop.AtomName(ctx.X, xproto.Atom(atom))
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		switch name {
+		case "_NET_WM_STATE_FULLSCREEN":
+			state.Fullscreen = apply(state.Fullscreen)
+		case "_NET_WM_STATE_MAXIMIZED_VERT":
+			state.MaximizedVert = apply(state.MaximizedVert)
+		case "_NET_WM_STATE_MAXIMIZED_HORZ":
+			state.MaximizedHorz = apply(state.MaximizedHorz)
+		case "_NET_WM_STATE_ABOVE":
+			state.Above = apply(state.Above)
+		case "_NET_WM_STATE_HIDDEN":
+			hidden = apply(hidden)
+		case "_NET_WM_STATE_DEMANDS_ATTENTION":
+			urgent = apply(urgent)
+		}
+	}
+
+	if urgent != f.Urgent {
+		f.SetUrgent(ctx, urgent)
+	}
+
+	if state != c.WmState {
+		c.SetWmState(ctx, f, state)
+	}
+	if hidden != c.Hidden {
+		c.ChangeMinimizationState(ctx)
+	}
+	c.UpdateWmState(ctx)
+}
+
+// ApplyRequestedWmState puts a newly mapped window's container in the state the client asked for before mapping.
+func ApplyRequestedWmState(ctx *Context, f *Frame) {
+	if f == nil || f.IsOrphan() || f.Window == nil {
+		return
+	}
+	requested, err := ewmh.WmStateGet(ctx.X, f.Window.Id)
+	if err != nil {
+		return
+	}
+
+	state := f.Container.WmState
+	for _, s := range requested {
+		switch s {
+		case "_NET_WM_STATE_FULLSCREEN":
+			state.Fullscreen = true
+		case "_NET_WM_STATE_MAXIMIZED_VERT":
+			state.MaximizedVert = true
+		case "_NET_WM_STATE_MAXIMIZED_HORZ":
+			state.MaximizedHorz = true
+		case "_NET_WM_STATE_ABOVE":
+			state.Above = true
+		}
+	}
+	if state != f.Container.WmState {
+		f.Container.SetWmState(ctx, f, state)
+	}
+}
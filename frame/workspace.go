@@ -0,0 +1,177 @@
+package frame
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/ewmh"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/ipc"
+	"time"
+)
+
+func init() {
+	SupportedHints = append(SupportedHints,
+		"_NET_NUMBER_OF_DESKTOPS",
+		"_NET_CURRENT_DESKTOP",
+		"_NET_WM_DESKTOP",
+	)
+}
+
+// WorkspaceId is a workspace on a particular screen, every screen has its own set of workspaces.
+type WorkspaceId struct {
+	Screen    int
+	Workspace int
+}
+
+// NumWorkspaces is how many workspaces each screen has, one for every workspace key.
+func (ctx *Context) NumWorkspaces() int {
+	return ext.IMax(len(ctx.Config.WorkspaceKeys), 1)
+}
+
+// CurrentWorkspace returns the workspace shown on a screen.
+func (ctx *Context) CurrentWorkspace(screen int) int {
+	for len(ctx.Workspaces) <= screen {
+		ctx.Workspaces = append(ctx.Workspaces, 0)
+	}
+	return ctx.Workspaces[screen]
+}
+
+// ScreenIndex returns the screen a container is on.
+func (ctx *Context) ScreenIndex(c *Container) int {
+	_, _, index := ctx.GetScreenForShape(c.Shape)
+	return index
+}
+
+// OnCurrentWorkspace checks whether the container is on the workspace shown on its screen.
+func (c *Container) OnCurrentWorkspace(ctx *Context) bool {
+	return c.Workspace == ctx.CurrentWorkspace(ctx.ScreenIndex(c))
+}
+
+// Shown checks whether the container should be mapped, it has to be on a shown workspace and not minimized.
+func (c *Container) Shown(ctx *Context) bool {
+	return !c.Hidden && c.OnCurrentWorkspace(ctx)
+}
+
+// ShowWorkspace switches the workspace shown on a screen, mapping and unmapping containers to match.
+// Focus is left to the caller.
+func (ctx *Context) ShowWorkspace(screen, workspace int) {
+	if ctx.CurrentWorkspace(screen) == workspace {
+		return
+	}
+
+	if focused := ctx.GetFocusedFrame(); focused != nil && !focused.IsOrphan() && ctx.ScreenIndex(focused.Container) == screen {
+		ctx.WorkspaceFocus[WorkspaceId{screen, ctx.Workspaces[screen]}] = focused.Window.Id
+	}
+
+	ctx.Workspaces[screen] = workspace
+	for c := range ctx.Containers {
+		if ctx.ScreenIndex(c) == screen {
+			c.UpdateFrameMappings(ctx)
+		}
+	}
+	ctx.Taskbar.Scroller.ShiftAndActivate(ctx)
+
+	ctx.UpdateWorkspaceHints()
+	ctx.Emit(ipc.Event{Type: ipc.EventWorkspace, Time: time.Now(), Workspaces: append([]int{}, ctx.Workspaces...)})
+}
+
+// SwitchWorkspace shows a workspace on the screen that was last focused,
+// and focuses the window that was focused when the workspace was last shown.
+func (ctx *Context) SwitchWorkspace(workspace int) {
+	if workspace < 0 || workspace >= ctx.NumWorkspaces() {
+		return
+	}
+	ctx.LastFocusedScreen()
+	screen := ctx.LastKnownFocusedScreen
+	ctx.ShowWorkspace(screen, workspace)
+	ctx.FocusWorkspace(screen)
+}
+
+// FocusWorkspace focuses the window last focused on the workspace shown on a screen,
+// falling back to any container on it, or the root window if it is empty.
+func (ctx *Context) FocusWorkspace(screen int) {
+	id := WorkspaceId{screen, ctx.CurrentWorkspace(screen)}
+	if f := ctx.Get(ctx.WorkspaceFocus[id]); f != nil && !f.IsOrphan() && f.IsLeaf() && f.Container.Shown(ctx) && ctx.ScreenIndex(f.Container) == screen {
+		f.FocusRaise(ctx)
+		return
+	}
+	for c := range ctx.Containers {
+		if c.Shown(ctx) && !c.Floating && ctx.ScreenIndex(c) == screen {
+			c.RaiseFindFocus(ctx)
+			return
+		}
+	}
+	ext.Focus(xwindow.New(ctx.X, ctx.X.RootWin()))
+	ctx.SetActiveWindow(0)
+}
+
+// MoveToWorkspace sends a container to another workspace on its screen.
+func (c *Container) MoveToWorkspace(ctx *Context, workspace int) {
+	if workspace < 0 || workspace >= ctx.NumWorkspaces() || workspace == c.Workspace {
+		return
+	}
+
+	focused := false
+	if ff := ctx.GetFocusedFrame(); ff != nil && ff.Container == c {
+		focused = true
+	}
+
+	c.Workspace = workspace
+	c.UpdateFrameMappings(ctx)
+	// Dialogs go along with the container they belong to
+	for oc := range ctx.Containers {
+		if oc.TransientFor == c {
+			oc.Workspace = workspace
+			oc.UpdateFrameMappings(ctx)
+		}
+	}
+	ctx.UpdateClientList()
+	ctx.Taskbar.Scroller.ShiftAndActivate(ctx)
+	ctx.Taskbar.UpdateWorkspaces(ctx)
+
+	if focused && !c.OnCurrentWorkspace(ctx) {
+		ctx.FocusWorkspace(ctx.ScreenIndex(c))
+	}
+}
+
+// ClampWorkspaces moves everything on workspaces that no longer exist (after the config changed) to the last one.
+func (ctx *Context) ClampWorkspaces() {
+	last := ctx.NumWorkspaces() - 1
+	for i := range ctx.Workspaces {
+		ctx.Workspaces[i] = ext.IMin(ctx.Workspaces[i], last)
+	}
+	for c := range ctx.Containers {
+		c.Workspace = ext.IMin(c.Workspace, last)
+	}
+}
+
+// UpdateWorkspaceHints publishes the number of workspaces and the one shown on the last focused screen,
+// for pagers and the taskbar. Pagers only know about a single set of workspaces, so they follow the screen in use.
+func (ctx *Context) UpdateWorkspaceHints() {
+	ext.Logerr(ewmh.NumberOfDesktopsSet(ctx.X, uint(ctx.NumWorkspaces())))
+	ext.Logerr(ewmh.CurrentDesktopSet(ctx.X, uint(ctx.CurrentWorkspace(ctx.LastKnownFocusedScreen))))
+	ctx.Taskbar.UpdateWorkspaces(ctx)
+}
+
+// UrgentWorkspaces returns the workspaces on a screen that have windows wanting attention.
+func (ctx *Context) UrgentWorkspaces(screen int) map[int]bool {
+	urgent := make(map[int]bool)
+	for c := range ctx.Containers {
+		if c.Root == nil || ctx.ScreenIndex(c) != screen {
+			continue
+		}
+		if c.Root.Find(func(f *Frame) bool { return f.IsLeaf() && f.Urgent }) != nil {
+			urgent[c.Workspace] = true
+		}
+	}
+	return urgent
+}
+
+// updateWmDesktop publishes the workspace of a managed window, skipping windows that are already up to date.
+func (ctx *Context) updateWmDesktop(w xproto.Window, workspace int) {
+	if published, ok := ctx.WmDesktops[w]; ok && published == workspace {
+		return
+	}
+	ctx.WmDesktops[w] = workspace
+	ext.Logerr(ewmh.WmDesktopSet(ctx.X, w, uint(workspace)))
+}
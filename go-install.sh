@@ -5,6 +5,7 @@ DIR=$(dirname $0)
 echo "Compiling..."
 go get github.com/levavakian/rowm
 go get github.com/levavakian/rowm/cmd/rowmbright
+go get github.com/levavakian/rowm/cmd/rowmctl
 echo "Installing to global directories"
 rm /usr/share/xsessions/rowm.desktop | true
 mkdir -p /usr/local/share/wingo/
@@ -5,6 +5,7 @@ go 1.16
 require (
 	github.com/BurntSushi/freetype-go v0.0.0-20160129220410-b763ddbfe298 // indirect
 	github.com/BurntSushi/graphics-go v0.0.0-20160129215708-b43f31a4a966 // indirect
+	github.com/BurntSushi/toml v1.3.2
 	github.com/BurntSushi/wingo v0.0.0-20201011141536-30b336cbb88d
 	github.com/BurntSushi/xdg v0.0.0-20130804141135-e80d3446fea1 // indirect
 	github.com/BurntSushi/xgb v0.0.0-20210121224620-deaf085860bc
@@ -0,0 +1,147 @@
+// ipc defines the messages spoken over the rowm command socket.
+// Each request and response is a single line of JSON.
+package ipc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Request asks the window manager to run a command.
+type Request struct {
+	Command string
+	Args    []string `json:",omitempty"`
+	Window  uint32   `json:",omitempty"` // The window to act on, the focused window if not set
+}
+
+// Response is the result of a request, Error is empty if the command succeeded.
+type Response struct {
+	Error string          `json:",omitempty"`
+	Data  json.RawMessage `json:",omitempty"`
+}
+
+// Rect is the shape of a frame or container in root window coordinates.
+type Rect struct {
+	X, Y, W, H int
+}
+
+// Frame is a node in a container's tree, either a window or a split between its children.
+type Frame struct {
+	Shape    Rect
+	Window   uint32   `json:",omitempty"`
+	Class    string   `json:",omitempty"`
+	Instance string   `json:",omitempty"`
+	Title    string   `json:",omitempty"`
+	Gotos    []string `json:",omitempty"` // Goto keys assigned to the window
+	Focused  bool     `json:",omitempty"`
+	Expanded bool     `json:",omitempty"`
+	Mapped   bool
+	Split    string    `json:",omitempty"` // horizontal, vertical or tabbed
+	Weights  []float64 `json:",omitempty"` // Share of the split given to each child
+	Children []*Frame  `json:",omitempty"`
+}
+
+// Container is a decorated window tree.
+type Container struct {
+	Shape             Rect
+	Hidden            bool
+	DecorationsHidden bool
+	Workspace         int
+	Root              *Frame
+}
+
+// Tree is the reply to the tree command, every container and its frames.
+type Tree struct {
+	Containers []Container
+}
+
+// SocketPath is where the command socket is served, under $XDG_RUNTIME_DIR if it is set.
+// Each display gets its own socket, so a nested rowm doesn't take over the one it runs in.
+func SocketPath() string {
+	display := strings.ReplaceAll(os.Getenv("DISPLAY"), "/", "_")
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return path.Join(dir, fmt.Sprintf("rowm-%s.sock", display))
+	}
+	return path.Join(os.TempDir(), fmt.Sprintf("rowm-%d-%s.sock", os.Getuid(), display))
+}
+
+// Event types sent to subscribers.
+const (
+	EventManage    = "manage"    // A window was mapped and put in a container
+	EventUnmanage  = "unmanage"  // A window unmapped itself and was taken out of its container
+	EventDestroy   = "destroy"   // A window was destroyed
+	EventFocus     = "focus"     // A different window was focused
+	EventMinimize  = "minimize"  // A container was minimized, Window is one of its windows
+	EventRestore   = "restore"   // A container was restored, Window is one of its windows
+	EventScreens   = "screens"   // The monitor layout changed
+	EventLock      = "lock"      // The screen was locked
+	EventUnlock    = "unlock"    // The screen was unlocked
+	EventWorkspace = "workspace" // A screen switched to another workspace
+)
+
+// Event is sent to subscribers whenever something they might want to react to happens.
+type Event struct {
+	Type       string
+	Time       time.Time
+	Window     uint32 `json:",omitempty"`
+	Class      string `json:",omitempty"`
+	Title      string `json:",omitempty"`
+	Screens    []Rect `json:",omitempty"`
+	Workspaces []int  `json:",omitempty"` // The workspace shown on each screen
+}
+
+// Client is a connection to the command socket.
+type Client struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+}
+
+// Dial connects to the command socket of the running window manager.
+func Dial() (*Client, error) {
+	conn, err := net.Dial("unix", SocketPath())
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, 16*1024*1024)
+	return &Client{conn: conn, scanner: scanner}, nil
+}
+
+// Call sends a request and waits for its response.
+func (c *Client) Call(req Request) (Response, error) {
+	resp := Response{}
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return resp, err
+	}
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return resp, err
+		}
+		return resp, fmt.Errorf("connection closed")
+	}
+	err := json.Unmarshal(c.scanner.Bytes(), &resp)
+	return resp, err
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ReadEvent waits for the next event, after a successful subscribe request.
+func (c *Client) ReadEvent() (Event, error) {
+	ev := Event{}
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return ev, err
+		}
+		return ev, fmt.Errorf("connection closed")
+	}
+	err := json.Unmarshal(c.scanner.Bytes(), &ev)
+	return ev, err
+}
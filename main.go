@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"github.com/BurntSushi/xgb/randr"
 	"github.com/BurntSushi/xgb/xproto"
 	"github.com/BurntSushi/xgbutil"
@@ -12,11 +13,22 @@ import (
 	"github.com/levavakian/rowm/root"
 	"github.com/levavakian/rowm/sideloop"
 	"log"
+	"os"
 	"os/exec"
 	"time"
 )
 
 func main() {
+	checkConfig := flag.Bool("check-config", false, "check a config file (default ~/.config/rowm/config.toml) for errors and exit")
+	flag.Parse()
+	if *checkConfig {
+		filename := frame.ConfigPath()
+		if flag.NArg() > 0 {
+			filename = flag.Arg(0)
+		}
+		os.Exit(CheckConfig(filename))
+	}
+
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 	log.Println("RoWM Window Manager")
 	log.Println("Hybrid Floating and Tiling Window Manager")
@@ -31,12 +43,15 @@ func main() {
 	inj := sideloop.NewInjector()
 
 	// Configure root hooks
-	if err := ConfigRoot(X, inj); err != nil {
+	ctx, err := ConfigRoot(X, inj)
+	if err != nil {
 		log.Fatal(err)
 	}
 
 	pingBefore, pingAfter, pingQuit := xevent.MainPing(X)
 	for {
+		// Publish the client lists once per event instead of on every change
+		ctx.FlushClientList()
 		select {
 		case <-pingBefore:
 			// Wait for the event to finish processing.
@@ -50,7 +65,7 @@ func main() {
 	}
 }
 
-func ConfigRoot(X *xgbutil.XUtil, inj *sideloop.Injector) error {
+func ConfigRoot(X *xgbutil.XUtil, inj *sideloop.Injector) (*frame.Context, error) {
 	var err error
 
 	// Init
@@ -91,39 +106,27 @@ func ConfigRoot(X *xgbutil.XUtil, inj *sideloop.Injector) error {
 	// Start monitor for screens
 	root.MonitorScreens(ctx, inj)
 
-	// Add base control hooks
-	err = root.RegisterBaseHooks(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Add splitting hooks
-	err = root.RegisterSplitHooks(ctx)
+	// Add root window hooks
+	err = root.RegisterRootHooks(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Add volume hooks
-	err = root.RegisterVolumeHooks(ctx)
+	// Add key hooks
+	err = root.RegisterKeyHooks(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Add backlight hooks
-	err = root.RegisterBrightnessHooks(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
+	// Manage windows that were around before we started
+	frame.AdoptWindows(ctx)
 
-	// Add alttab-like hooks
-	root.RegisterChooseHooks(ctx)
+	// Reload config on changes
+	root.WatchConfig(ctx, inj)
 
-	// Add taskbar hooks
-	err = root.RegisterTaskbarHooks(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
+	// Accept commands from scripts
+	root.ServeIPC(ctx, inj)
 	sideloop.NewRepeater(func() { ctx.Taskbar.Update(ctx) }, 1*time.Second, inj)
 
-	return err
+	return ctx, err
 }
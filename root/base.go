@@ -10,6 +10,8 @@ import (
 	"github.com/levavakian/rowm/ext"
 	"github.com/levavakian/rowm/frame"
 	"log"
+	"os"
+	"syscall"
 	"time"
 )
 
@@ -17,19 +19,19 @@ func RegisterBaseHooks(ctx *frame.Context) error {
 	var err error
 
 	err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		ext.Logerr(ctx.SaveState())
 		xevent.Quit(ctx.X)
 	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.Shutdown, true)
 	if err != nil {
 		return err
 	}
 
-	err = mousebind.ButtonPressFun(func(X *xgbutil.XUtil, ev xevent.ButtonPressEvent) {
+	err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
 		if ctx.Locked {
 			return
 		}
-		ext.Focus(xwindow.New(ctx.X, ctx.X.RootWin()))
-		xproto.AllowEvents(ctx.X.Conn(), xproto.AllowReplayPointer, 0)
-	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.ButtonClick, false, false)
+		Restart(ctx)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.Restart.Data, true)
 	if err != nil {
 		return err
 	}
@@ -41,68 +43,139 @@ func RegisterBaseHooks(ctx *frame.Context) error {
 		return err
 	}
 
-	focusNext := func(X *xgbutil.XUtil, ev xevent.KeyReleaseEvent, reverse bool) {
+	err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, ev xevent.KeyReleaseEvent) {
 		if ctx.Locked {
 			return
 		}
-		ffoc := ctx.GetFocusedFrame()
-		if ffoc == nil || ffoc.IsOrphan() {
+		FocusNext(ctx, false)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.FocusNext.Data, true)
+	if err != nil {
+		return err
+	}
+	err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, ev xevent.KeyReleaseEvent) {
+		if ctx.Locked {
 			return
 		}
-		nfoc := ffoc.FindNextLeaf(func(nf *frame.Frame) bool { return nf.IsLeaf() && nf.Mapped }, reverse, ffoc.Container.ActiveRoot())
-		if nfoc != nil {
-			nfoc.Container.Raise(ctx)
-			nfoc.Focus(ctx)
-
-			// Get the center and show a little square to mark where the thing is
-			if ctx.FocusMarker != nil {
-				ctx.FocusMarker.Unmap()
-				ctx.FocusMarker.Destroy()
-			}
-
-			fshape := nfoc.CalcShape(ctx)
-			dshape := frame.Rect{
-				X: fshape.X + (fshape.W / 2) - (ctx.Config.ElemSize / 2),
-				Y: fshape.Y + (fshape.H / 2) - (ctx.Config.ElemSize / 2),
-				W: ctx.Config.ElemSize,
-				H: ctx.Config.ElemSize,
-			}
-			decoration, err := frame.CreateDecoration(ctx, dshape, ctx.Config.FocusColor, 0)
-			decoration.Window.Map()
-			if err != nil {
-				log.Println(err)
-				return
-			}
-			ctx.FocusMarker = decoration.Window
-			go func() {
-				time.Sleep(ctx.Config.FocusMarkerTime)
-				ctx.Injector.Do(func() {
-					if ctx.FocusMarker != decoration.Window {
-						return
-					}
-					decoration.Window.Unmap()
-					decoration.Window.Destroy()
-				},
-				)
-			}()
-		}
+		FocusNext(ctx, true)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.FocusPrev.Data, true)
+	if err != nil {
+		return err
 	}
+
 	err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, ev xevent.KeyReleaseEvent) {
-		focusNext(X, ev, false)
-	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.FocusNext.Data, true)
+		if ctx.Locked {
+			return
+		}
+		ReloadConfig(ctx)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.ReloadConfig.Data, true)
 	if err != nil {
 		return err
 	}
-	err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, ev xevent.KeyReleaseEvent) {
-		focusNext(X, ev, true)
-	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.FocusPrev.Data, true)
+
+	return nil
+}
+
+// FocusNext focuses the next (or previous) leaf in the focused container and briefly marks where it is.
+func FocusNext(ctx *frame.Context, reverse bool) {
+	ffoc := ctx.GetFocusedFrame()
+	if ffoc == nil || ffoc.IsOrphan() {
+		return
+	}
+	var nfoc *frame.Frame
+	// Within a tab group, cycle through its tabs instead
+	if tab := ffoc.NextTab(reverse); tab != nil {
+		nfoc = tab.Find(func(nf *frame.Frame) bool { return nf.IsLeaf() })
+	} else {
+		nfoc = ffoc.FindNextLeaf(func(nf *frame.Frame) bool { return nf.IsLeaf() && nf.Mapped }, reverse, ffoc.Container.ActiveRoot())
+	}
+	if nfoc != nil {
+		nfoc.Container.Raise(ctx)
+		nfoc.Focus(ctx)
+		MarkFocus(ctx, nfoc)
+	}
+}
+
+// MarkFocus briefly shows a little square in the middle of a frame to mark where it is.
+func MarkFocus(ctx *frame.Context, f *frame.Frame) {
+	if ctx.FocusMarker != nil {
+		ctx.FocusMarker.Unmap()
+		ctx.FocusMarker.Destroy()
+	}
+
+	fshape := f.CalcShape(ctx)
+	dshape := frame.Rect{
+		X: fshape.X + (fshape.W / 2) - (ctx.Config.ElemSize / 2),
+		Y: fshape.Y + (fshape.H / 2) - (ctx.Config.ElemSize / 2),
+		W: ctx.Config.ElemSize,
+		H: ctx.Config.ElemSize,
+	}
+	decoration, err := frame.CreateDecoration(ctx, dshape, ctx.Config.FocusColor, 0)
+	decoration.Window.Map()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	ctx.FocusMarker = decoration.Window
+	go func() {
+		time.Sleep(ctx.Config.FocusMarkerTime)
+		ctx.Injector.Do(func() {
+			if ctx.FocusMarker != decoration.Window {
+				return
+			}
+			decoration.Window.Unmap()
+			decoration.Window.Destroy()
+		},
+		)
+	}()
+}
+
+// Restart replaces the running window manager with a fresh copy of its binary.
+// The layout is saved and every client is mapped first, so the new process
+// adopts minimized windows as well and puts everything back where it was.
+func Restart(ctx *frame.Context) {
+	exe, err := os.Executable()
+	if err != nil {
+		log.Println("could not restart:", err)
+		return
+	}
+
+	if err := ctx.SaveState(); err != nil {
+		log.Println("could not save layout:", err)
+	}
+
+	for _, f := range ctx.Tracked {
+		if f.IsLeaf() && f.Window != nil {
+			f.Window.Map()
+		}
+	}
+	// Round trip so every request reaches the server before the connection goes away
+	xproto.GetInputFocus(ctx.X.Conn()).Reply()
+
+	log.Println("restarting", exe)
+	err = syscall.Exec(exe, os.Args, os.Environ())
+	log.Println("could not restart:", err)
+}
+
+// RegisterRootHooks connects the non key callbacks on the root window, these only need to be connected once.
+func RegisterRootHooks(ctx *frame.Context) error {
+	err := mousebind.ButtonPressFun(func(X *xgbutil.XUtil, ev xevent.ButtonPressEvent) {
+		if ctx.Locked {
+			return
+		}
+		ext.Focus(xwindow.New(ctx.X, ctx.X.RootWin()))
+		ctx.SetActiveWindow(0)
+		xproto.AllowEvents(ctx.X.Conn(), xproto.AllowReplayPointer, 0)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.ButtonClick, false, false)
 	if err != nil {
 		return err
 	}
 
 	xevent.MapRequestFun(func(X *xgbutil.XUtil, ev xevent.MapRequestEvent) {
-		frame.NewWindow(ctx, ev.Window)
+		frame.ApplyRequestedWmState(ctx, frame.NewWindow(ctx, ev.Window))
 		ctx.RaiseLock()
 	}).Connect(ctx.X, ctx.X.RootWin())
-	return nil
+
+	RegisterWorkspaceRootHooks(ctx)
+	RegisterMouseHooks(ctx)
+	return frame.SetupEwmh(ctx)
 }
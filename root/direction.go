@@ -0,0 +1,74 @@
+package root
+
+import (
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/levavakian/rowm/frame"
+)
+
+// FocusDirection focuses the closest frame in a direction from the focused one, in any container on any screen.
+func FocusDirection(ctx *frame.Context, dir frame.AnchorType) {
+	focused := ctx.GetFocusedFrame()
+	if focused == nil || focused.IsOrphan() {
+		return
+	}
+	n := focused.Neighbor(ctx, dir)
+	if n == nil {
+		return
+	}
+	n.FocusRaise(ctx)
+	MarkFocus(ctx, n)
+}
+
+// SwapDirection swaps the focused window with the closest one in a direction, focus follows the window.
+func SwapDirection(ctx *frame.Context, dir frame.AnchorType) {
+	focused := ctx.GetFocusedFrame()
+	if focused == nil || focused.IsOrphan() {
+		return
+	}
+	n := focused.Neighbor(ctx, dir)
+	if n == nil {
+		return
+	}
+	focused.SwapWindow(ctx, n)
+	if n.Container != focused.Container {
+		focused.Container.Raise(ctx)
+	}
+	n.FocusRaise(ctx)
+	MarkFocus(ctx, n)
+}
+
+func RegisterDirectionHooks(ctx *frame.Context) error {
+	bindings := map[frame.AnchorType][2]string{
+		frame.LEFT:   {ctx.Config.FocusLeft.Data, ctx.Config.SwapLeft.Data},
+		frame.BOTTOM: {ctx.Config.FocusDown.Data, ctx.Config.SwapDown.Data},
+		frame.TOP:    {ctx.Config.FocusUp.Data, ctx.Config.SwapUp.Data},
+		frame.RIGHT:  {ctx.Config.FocusRight.Data, ctx.Config.SwapRight.Data},
+	}
+	for d, keys := range bindings {
+		dir := d // capture separately so we can use in closure
+		err := keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			if ctx.Locked {
+				return
+			}
+
+			FocusDirection(ctx, dir)
+		}).Connect(ctx.X, ctx.X.RootWin(), keys[0], true)
+		if err != nil {
+			return err
+		}
+
+		err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			if ctx.Locked {
+				return
+			}
+
+			SwapDirection(ctx, dir)
+		}).Connect(ctx.X, ctx.X.RootWin(), keys[1], true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
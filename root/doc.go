@@ -8,5 +8,13 @@ launchers.go - callbacks for prompts that launch new windows (including the pari
 monitor.go - a side event loop that monitors for changes of the screen configuration
 volume.go - callbacks for raising/lowering/muting volume
 taskbar.go - callbacks for interacting with the taskbar
+reload.go - reloading the config on a keybinding or when the config file changes
+workspace.go - callbacks for switching workspaces and moving containers between them
+scratchpad.go - callbacks for moving containers into the scratchpad and summoning it
+resize.go - the resize mode that lets frames and containers be resized with the arrow keys
+direction.go - callbacks for moving the focus and swapping windows in a direction
+mouse.go - moving and resizing containers by dragging anywhere on them with a modifier held
+history.go - callbacks for undoing and redoing layout changes
+ipc.go - the command socket that lets scripts drive the window manager
 */
 package root
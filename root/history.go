@@ -0,0 +1,29 @@
+package root
+
+import (
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/levavakian/rowm/frame"
+)
+
+func RegisterHistoryHooks(ctx *frame.Context) error {
+	err := keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		if ctx.Locked {
+			return
+		}
+
+		ctx.UndoLayout()
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.UndoLayout.Data, true)
+	if err != nil {
+		return err
+	}
+
+	return keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		if ctx.Locked {
+			return
+		}
+
+		ctx.RedoLayout()
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.RedoLayout.Data, true)
+}
@@ -0,0 +1,416 @@
+package root
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil/icccm"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/frame"
+	"github.com/levavakian/rowm/ipc"
+	"github.com/levavakian/rowm/sideloop"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// directions are the names of the directions commands take.
+var directions = map[string]frame.AnchorType{
+	"up":    frame.TOP,
+	"down":  frame.BOTTOM,
+	"left":  frame.LEFT,
+	"right": frame.RIGHT,
+}
+
+// ipcCommand runs a request on the X loop. target is the window named in the request,
+// or the focused window if none was, and can be nil if nothing is focused.
+type ipcCommand func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error)
+
+var ipcCommands = map[string]ipcCommand{
+	"split": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		if len(args) < 2 {
+			return nil, fmt.Errorf("usage: split horizontal|vertical|tabbed COMMAND")
+		}
+		partition, err := partitionArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+		return nil, SplitCommand(ctx, target, partition, strings.Join(args[1:], " "))
+	},
+	"yank": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		if target == nil {
+			return nil, fmt.Errorf("no window to yank")
+		}
+		switch argOr(args, "frame") {
+		case "frame":
+			ctx.Yanked = &frame.Yank{Window: target.Window.Id, Container: nil}
+		case "container":
+			ctx.Yanked = &frame.Yank{Window: 0, Container: target.Container}
+		default:
+			return nil, fmt.Errorf("usage: yank [frame|container]")
+		}
+		return nil, nil
+	},
+	"scratchpad": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		switch argOr(args, "toggle") {
+		case "toggle":
+			ctx.ToggleScratchpad()
+		case "mark":
+			if target == nil || target.IsOrphan() {
+				return nil, fmt.Errorf("no window to move to the scratchpad")
+			}
+			ctx.MarkScratchpad(target.Container)
+		default:
+			return nil, fmt.Errorf("usage: scratchpad [toggle|mark]")
+		}
+		return nil, nil
+	},
+	"paste": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		partition, err := partitionArg(argOr(args, "horizontal"))
+		if err != nil {
+			return nil, err
+		}
+		if target == nil {
+			return nil, fmt.Errorf("no window to paste into")
+		}
+		if ctx.Yanked == nil {
+			return nil, fmt.Errorf("nothing has been yanked")
+		}
+		if frame.PasteYanked(ctx, target, partition) == nil {
+			return nil, fmt.Errorf("could not paste")
+		}
+		return nil, nil
+	},
+	"pop": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		if target == nil {
+			return nil, fmt.Errorf("no window to pop")
+		}
+		target.Pop(ctx)
+		return nil, nil
+	},
+	"minimize": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		if target == nil {
+			return nil, fmt.Errorf("no window to minimize")
+		}
+		target.Container.ChangeMinimizationState(ctx)
+		return nil, nil
+	},
+	"anchor": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		direction, ok := directions[argOr(args, "")]
+		if !ok {
+			return nil, fmt.Errorf("usage: anchor up|down|left|right")
+		}
+		if target == nil {
+			return nil, fmt.Errorf("no window to anchor")
+		}
+		target.Container.MoveToAnchor(ctx, direction)
+		return nil, nil
+	},
+	"focus": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		switch argOr(args, "") {
+		case "next":
+			FocusNext(ctx, false)
+		case "prev":
+			FocusNext(ctx, true)
+		case "up", "down", "left", "right":
+			FocusDirection(ctx, directions[argOr(args, "")])
+		case "":
+			if target == nil {
+				return nil, fmt.Errorf("no window to focus")
+			}
+			if target.Container.Hidden {
+				target.Container.ChangeMinimizationState(ctx)
+			}
+			target.FocusRaise(ctx)
+		default:
+			return nil, fmt.Errorf("usage: focus [next|prev|up|down|left|right]")
+		}
+		return nil, nil
+	},
+	"arrange": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		if target == nil {
+			return nil, fmt.Errorf("no window to arrange")
+		}
+		switch argOr(args, "") {
+		case "rotate":
+			target.Rotate(ctx)
+		case "flip":
+			target.Flip(ctx)
+		case "equalize":
+			target.Container.Equalize(ctx)
+		case "balance":
+			target.Container.Balance(ctx)
+		default:
+			return nil, fmt.Errorf("usage: arrange rotate|flip|equalize|balance")
+		}
+		return nil, nil
+	},
+	"undo": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		ctx.UndoLayout()
+		return nil, nil
+	},
+	"redo": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		ctx.RedoLayout()
+		return nil, nil
+	},
+	"swap": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		direction, ok := directions[argOr(args, "")]
+		if !ok {
+			return nil, fmt.Errorf("usage: swap up|down|left|right")
+		}
+		SwapDirection(ctx, direction)
+		return nil, nil
+	},
+	"taskbar": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		ToggleTaskbar(ctx)
+		return nil, nil
+	},
+	"lock": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		ctx.SetLocked(true)
+		return nil, nil
+	},
+	"tree": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		return Tree(ctx), nil
+	},
+	"run": func(ctx *frame.Context, target *frame.Frame, args []string) (interface{}, error) {
+		name := strings.Join(args, " ")
+		for k, cmd := range ctx.Config.BuiltinCommands {
+			if k.Data == name || strings.EqualFold(strings.TrimSpace(k.Help), name) {
+				return nil, RunCommand(cmd)
+			}
+		}
+		return nil, fmt.Errorf("no builtin command with key or name %q", name)
+	},
+}
+
+// Tree describes every container and its frames, sorted by the first window in each.
+func Tree(ctx *frame.Context) ipc.Tree {
+	gotos := make(map[xproto.Window][]string)
+	for k, w := range ctx.Gotos {
+		gotos[w] = append(gotos[w], k)
+	}
+	focused := ctx.GetFocusedFrame()
+
+	var describe func(f *frame.Frame) *ipc.Frame
+	describe = func(f *frame.Frame) *ipc.Frame {
+		if f == nil {
+			return nil
+		}
+		d := &ipc.Frame{
+			Shape:    ipc.Rect{X: f.Shape.X, Y: f.Shape.Y, W: f.Shape.W, H: f.Shape.H},
+			Focused:  f == focused,
+			Expanded: f.Container.Expanded == f,
+			Mapped:   f.Mapped,
+		}
+		for _, child := range f.Children {
+			d.Children = append(d.Children, describe(child))
+			d.Weights = append(d.Weights, child.Share())
+		}
+		if !f.IsLeaf() {
+			switch f.Separator.Type {
+			case frame.VERTICAL:
+				d.Split = "vertical"
+			case frame.TABBED:
+				d.Split = "tabbed"
+			default:
+				d.Split = "horizontal"
+			}
+			return d
+		}
+
+		d.Window = uint32(f.Window.Id)
+		if class, err := icccm.WmClassGet(ctx.X, f.Window.Id); err == nil {
+			d.Class = class.Class
+			d.Instance = class.Instance
+		}
+		d.Title = ext.WindowTitle(ctx.X, f.Window.Id)
+		d.Gotos = gotos[f.Window.Id]
+		sort.Strings(d.Gotos)
+		return d
+	}
+
+	tree := ipc.Tree{Containers: make([]ipc.Container, 0, len(ctx.Containers))}
+	for c := range ctx.Containers {
+		if c.Root == nil {
+			continue
+		}
+		tree.Containers = append(tree.Containers, ipc.Container{
+			Shape:             ipc.Rect{X: c.Shape.X, Y: c.Shape.Y, W: c.Shape.W, H: c.Shape.H},
+			Hidden:            c.Hidden,
+			DecorationsHidden: c.Decorations.Hidden,
+			Workspace:         c.Workspace,
+			Root:              describe(c.Root),
+		})
+	}
+
+	// Containers are kept in a map, sort them so the output is stable
+	first := func(f *ipc.Frame) uint32 {
+		for len(f.Children) > 0 {
+			f = f.Children[0]
+		}
+		return f.Window
+	}
+	sort.Slice(tree.Containers, func(i, j int) bool {
+		return first(tree.Containers[i].Root) < first(tree.Containers[j].Root)
+	})
+	return tree
+}
+
+func argOr(args []string, def string) string {
+	if len(args) == 0 {
+		return def
+	}
+	return strings.ToLower(args[0])
+}
+
+func partitionArg(arg string) (frame.PartitionType, error) {
+	switch strings.ToLower(arg) {
+	case "horizontal":
+		return frame.HORIZONTAL, nil
+	case "vertical":
+		return frame.VERTICAL, nil
+	case "tabbed":
+		return frame.TABBED, nil
+	}
+	return frame.HORIZONTAL, fmt.Errorf("expected horizontal, vertical or tabbed, not %q", arg)
+}
+
+// HandleRequest runs a single request, it must be called from the X loop.
+func HandleRequest(ctx *frame.Context, req ipc.Request) ipc.Response {
+	command, ok := ipcCommands[req.Command]
+	if !ok {
+		return ipc.Response{Error: fmt.Sprintf("unknown command %q", req.Command)}
+	}
+	if ctx.Locked {
+		return ipc.Response{Error: "screen is locked"}
+	}
+
+	target := ctx.GetFocusedFrame()
+	if req.Window != 0 {
+		target = ctx.Get(xproto.Window(req.Window))
+		if target == nil || target.IsOrphan() {
+			return ipc.Response{Error: fmt.Sprintf("window %d is not managed", req.Window)}
+		}
+	}
+	if target != nil && target.IsOrphan() {
+		target = nil
+	}
+
+	data, err := command(ctx, target, req.Args)
+	if err != nil {
+		return ipc.Response{Error: err.Error()}
+	}
+	if data == nil {
+		return ipc.Response{}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return ipc.Response{Error: err.Error()}
+	}
+	return ipc.Response{Data: raw}
+}
+
+// ServeIPC listens on the command socket, handling every request in line with the X event loop.
+func ServeIPC(ctx *frame.Context, inj *sideloop.Injector) {
+	socket := ipc.SocketPath()
+
+	// A socket left behind by a crash or restart would stop us from listening, but one that still
+	// accepts connections belongs to a running instance
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		log.Println("command socket", socket, "is already in use")
+		return
+	} else if errors.Is(err, syscall.ECONNREFUSED) {
+		ext.Logerr(os.Remove(socket))
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		log.Println("could not listen on command socket:", err)
+		return
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		log.Println(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			go serveConn(ctx, inj, conn)
+		}
+	}()
+}
+
+func serveConn(ctx *frame.Context, inj *sideloop.Injector, conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req ipc.Request
+		resp := ipc.Response{}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = err.Error()
+		} else if req.Command == "subscribe" {
+			streamEvents(ctx, inj, conn, req.Args)
+			return
+		} else {
+			inj.Do(func() {
+				resp = HandleRequest(ctx, req)
+			})
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// streamEvents turns the connection into a stream of events, one JSON object per line,
+// until the client goes away. Only events of the given types are sent, or all of them if none are given.
+func streamEvents(ctx *frame.Context, inj *sideloop.Injector, conn net.Conn, types []string) {
+	var events chan ipc.Event
+	inj.Do(func() {
+		events = ctx.Subscribe()
+	})
+	unsubscribe := func() {
+		inj.Do(func() {
+			ctx.Unsubscribe(events)
+		})
+	}
+
+	// Nothing more is read from a subscriber, so the read only returns once the client hangs up
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		unsubscribe()
+	}()
+
+	wanted := make(map[string]bool)
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	enc := json.NewEncoder(conn)
+	if err := enc.Encode(ipc.Response{}); err != nil {
+		unsubscribe()
+		return
+	}
+	for ev := range events {
+		if len(wanted) > 0 && !wanted[ev.Type] {
+			continue
+		}
+		if err := enc.Encode(ev); err != nil {
+			unsubscribe()
+			return
+		}
+	}
+}
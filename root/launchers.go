@@ -12,6 +12,7 @@ import (
 	"os/exec"
 	"time"
 	"reflect"
+	"strings"
 )
 
 func GenerateHelp(ctx *frame.Context) string {
@@ -85,6 +86,34 @@ func Split(ctx *frame.Context) *frame.Frame {
 	return attachF
 }
 
+// RunCommand starts a command through bash without waiting for it to finish.
+func RunCommand(command string) error {
+	cmd := exec.Command("bash", "-c", command)
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	go func() {
+		cmd.Wait()
+	}()
+	return nil
+}
+
+// SplitCommand runs a command and splits the target frame to make room for its window.
+func SplitCommand(ctx *frame.Context, attachF *frame.Frame, partition frame.PartitionType, command string) error {
+	if attachF == nil || attachF.IsOrphan() {
+		return fmt.Errorf("cannot split when not focused on a window")
+	}
+	if err := RunCommand(command); err != nil {
+		return err
+	}
+	ctx.AttachPoint = &frame.AttachTarget{
+		Target: attachF,
+		Type:   partition,
+	}
+	return nil
+}
+
 func RegisterSplitHooks(ctx *frame.Context) error {
 
 	var err error
@@ -96,14 +125,7 @@ func RegisterSplitHooks(ctx *frame.Context) error {
 				if ctx.Locked {
 					return
 				}
-				cmd := exec.Command("bash", "-c", ncmd)
-				err := cmd.Start()
-				if err != nil {
-					log.Println(err)
-				}
-				go func() {
-					cmd.Wait()
-				}()
+				RunCommand(ncmd)
 			}).Connect(ctx.X, ctx.X.RootWin(), k.Data, true)
 		if err != nil {
 			return err
@@ -219,3 +241,54 @@ func RegisterSplitHooks(ctx *frame.Context) error {
 
 	return err
 }
+
+// LaunchLayout starts a layout from the config, showing a message if it can't be found.
+func LaunchLayout(ctx *frame.Context, name string) {
+	if err := ctx.LaunchLayout(name); err != nil {
+		log.Println(err)
+		msgPrompt := prompt.NewMessage(ctx.X, prompt.DefaultMessageTheme, prompt.DefaultMessageConfig)
+		msgPrompt.Show(ctx.Screens[0].ToXRect(), err.Error(), 2*time.Second, func(msg *prompt.Message) {})
+	}
+}
+
+func RegisterLayoutHooks(ctx *frame.Context) error {
+	var err error
+	for name, l := range ctx.Config.Layouts {
+		if l.Key == "" {
+			continue
+		}
+		ref := name // capture separately so we can use in closure
+		err = keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			if ctx.Locked {
+				return
+			}
+			LaunchLayout(ctx, ref)
+		}).Connect(ctx.X, ctx.X.RootWin(), l.Key, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Prompt for any layout by name, including ones without a key
+	return keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		if ctx.Locked {
+			return
+		}
+
+		names := make([]string, 0, len(ctx.Config.Layouts))
+		for name := range ctx.Config.Layouts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		inPrompt := prompt.NewInput(X, prompt.DefaultInputTheme, prompt.DefaultInputConfig)
+		canc := func(inp *prompt.Input) {
+			inPrompt.Destroy()
+		}
+		resp := func(inp *prompt.Input, text string) {
+			inPrompt.Destroy()
+			LaunchLayout(ctx, strings.TrimSpace(text))
+		}
+		inPrompt.Show(ctx.Screens[0].ToXRect(), fmt.Sprintf("Layout (%s):", strings.Join(names, ", ")), resp, canc)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.LaunchLayout.Data, true)
+}
@@ -0,0 +1,98 @@
+package root
+
+import (
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/mousebind"
+	"github.com/BurntSushi/xgbutil/xcursor"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/frame"
+)
+
+// RegisterMouseHooks lets containers be moved and resized by dragging anywhere on them with a modifier held,
+// which also works when their decorations are hidden.
+func RegisterMouseHooks(ctx *frame.Context) {
+	var c *frame.Container
+	var left, top bool
+
+	begin := func(rX, rY int) bool {
+		if ctx.Locked {
+			return false
+		}
+		c = frame.ContainerAt(ctx, rX, rY, true)
+		// Fullscreen and maximized containers stay where they are until they are restored
+		if c == nil || !c.WmState.IsNormal() {
+			return false
+		}
+		ctx.RecordLayout()
+		c.DragContext = frame.GenerateDragContext(ctx, c, nil, rX, rY)
+		c.RaiseFindFocus(ctx)
+		return true
+	}
+	end := func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
+		if c.Root != nil {
+			c.RaiseFindFocus(ctx)
+		}
+	}
+
+	mousebind.Drag(
+		ctx.X, ctx.X.RootWin(), ctx.X.RootWin(), ctx.Config.ButtonMoveContainer, true,
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			return begin(rX, rY), ctx.Cursors[xcursor.Fleur]
+		},
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
+			if c.Root == nil {
+				return
+			}
+			dX := rX - c.DragContext.MouseX
+			dY := rY - c.DragContext.MouseY
+			c.MoveResize(ctx, c.DragContext.Container.X+dX, c.DragContext.Container.Y+dY, c.Shape.W, c.Shape.H)
+		},
+		end,
+	)
+
+	mousebind.Drag(
+		ctx.X, ctx.X.RootWin(), ctx.X.RootWin(), ctx.Config.ButtonResizeContainer, true,
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) (bool, xproto.Cursor) {
+			if !begin(rX, rY) {
+				return false, 0
+			}
+			// Resize from whichever corner is closest to the pointer
+			left = 2*rX < 2*c.Shape.X+c.Shape.W
+			top = 2*rY < 2*c.Shape.Y+c.Shape.H
+			cursors := map[[2]bool]int{
+				{true, true}:   xcursor.TopLeftCorner,
+				{false, true}:  xcursor.TopRightCorner,
+				{true, false}:  xcursor.BottomLeftCorner,
+				{false, false}: xcursor.BottomRightCorner,
+			}
+			return true, ctx.Cursors[cursors[[2]bool{left, top}]]
+		},
+		func(X *xgbutil.XUtil, rX, rY, eX, eY int) {
+			if c.Root == nil {
+				return
+			}
+			orig := c.DragContext.Container
+			min := c.MinShape(ctx)
+			dX := rX - c.DragContext.MouseX
+			dY := rY - c.DragContext.MouseY
+			if left {
+				dX = -dX
+			}
+			if top {
+				dY = -dY
+			}
+			w := ext.IMax(orig.W+dX, min.W)
+			h := ext.IMax(orig.H+dY, min.H)
+			x, y := orig.X, orig.Y
+			if left {
+				x = orig.X + orig.W - w
+			}
+			if top {
+				y = orig.Y + orig.H - h
+			}
+			c.MoveResize(ctx, x, y, w, h)
+		},
+		end,
+	)
+}
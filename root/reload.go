@@ -0,0 +1,165 @@
+package root
+
+import (
+	"github.com/BurntSushi/wingo/prompt"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/frame"
+	"github.com/levavakian/rowm/sideloop"
+	"log"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// RegisterKeyHooks connects every key binding on the root window.
+// It is run once at startup, and again every time the config is reloaded.
+func RegisterKeyHooks(ctx *frame.Context) error {
+	var err error
+
+	// Add base control hooks
+	err = RegisterBaseHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add splitting hooks
+	err = RegisterSplitHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add layout hooks
+	err = RegisterLayoutHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add workspace hooks
+	err = RegisterWorkspaceHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add directional focus hooks
+	err = RegisterDirectionHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add keyboard resizing hooks
+	err = RegisterResizeHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add undo and redo hooks
+	err = RegisterHistoryHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add scratchpad hooks
+	err = RegisterScratchpadHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add volume hooks
+	err = RegisterVolumeHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add backlight hooks
+	err = RegisterBrightnessHooks(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Add alttab-like hooks
+	RegisterChooseHooks(ctx)
+
+	// Add taskbar hooks
+	return RegisterTaskbarHooks(ctx)
+}
+
+// ReloadConfig reads the config file again and applies it without disturbing any open windows.
+// If the new config can't be used, the current one is kept.
+func ReloadConfig(ctx *frame.Context) {
+	showMessage := func(text string) {
+		msgPrompt := prompt.NewMessage(ctx.X, prompt.DefaultMessageTheme, prompt.DefaultMessageConfig)
+		msgPrompt.Show(ctx.Screens[0].ToXRect(), text, 2*time.Second, func(msg *prompt.Message) {})
+	}
+
+	conf, err := frame.LoadConfig(frame.ConfigPath())
+	if err == nil {
+		err = conf.ResolveKeyBindings(ctx.X)
+	}
+	if err != nil {
+		log.Println("not reloading config:", err)
+		showMessage("Could not reload config: " + err.Error())
+		return
+	}
+
+	ctx.ApplyConfig(conf)
+	err = ext.RebindKeys(ctx.X, func() error {
+		return RegisterKeyHooks(ctx)
+	}, ctx.X.RootWin(), ctx.X.Dummy())
+	ext.Logerr(err)
+	log.Println("reloaded config")
+	showMessage("Reloaded config")
+}
+
+// WatchConfig runs a side loop listening for writes to the config file, reloading it on every change.
+func WatchConfig(ctx *frame.Context, inj *sideloop.Injector) {
+	go func() {
+		filename := frame.ConfigPath()
+		// The directory has to exist to be watched, so a config created later is still picked up
+		if err := os.MkdirAll(path.Dir(filename), 0755); err != nil {
+			log.Println("could not watch config:", err)
+			return
+		}
+		fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+		if err != nil {
+			log.Println("could not watch config:", err)
+			return
+		}
+		defer syscall.Close(fd)
+
+		// Watch the directory instead of the file, since editors often replace the file when saving
+		_, err = syscall.InotifyAddWatch(fd, path.Dir(filename), syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO)
+		if err != nil {
+			log.Println("could not watch config:", err)
+			return
+		}
+
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := syscall.Read(fd, buf)
+			if err != nil {
+				log.Println("stopped watching config:", err)
+				return
+			}
+
+			changed := false
+			for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+				ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+				nameStart := offset + syscall.SizeofInotifyEvent
+				name := strings.TrimRight(string(buf[nameStart:nameStart+int(ev.Len)]), "\x00")
+				if name == path.Base(filename) {
+					changed = true
+				}
+				offset = nameStart + int(ev.Len)
+			}
+
+			if changed {
+				inj.Do(func() {
+					ReloadConfig(ctx)
+				})
+			}
+		}
+	}()
+}
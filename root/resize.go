@@ -0,0 +1,153 @@
+package root
+
+import (
+	"fmt"
+	"github.com/BurntSushi/wingo/prompt"
+	"github.com/BurntSushi/xgb/xproto"
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/BurntSushi/xgbutil/xgraphics"
+	"github.com/BurntSushi/xgbutil/xwindow"
+	"github.com/levavakian/rowm/ext"
+	"github.com/levavakian/rowm/frame"
+	"log"
+	"strings"
+)
+
+// ResizeMode is active while the keyboard is grabbed for resizing, the OSD shows the ratios being changed.
+type ResizeMode struct {
+	OSD     *xwindow.Window
+	Resized bool // Whether anything was resized since entering, the layout from before is recorded once it is
+}
+
+// resizeLabel describes the separators around a frame and the size of its container.
+func resizeLabel(f *frame.Frame) string {
+	parts := make([]string, 0, 3)
+	if p, k := f.ResizeSeparator(frame.RIGHT); p != nil {
+		parts = append(parts, fmt.Sprintf("horizontal %.0f%%", 100*p.Children[k].Share()))
+	}
+	if p, k := f.ResizeSeparator(frame.BOTTOM); p != nil {
+		parts = append(parts, fmt.Sprintf("vertical %.0f%%", 100*p.Children[k].Share()))
+	}
+	parts = append(parts, fmt.Sprintf("%dx%d", f.Container.Shape.W, f.Container.Shape.H))
+	return strings.Join(parts, " | ")
+}
+
+// Update redraws the OSD in the middle of the focused frame, leaving resize mode if there is none.
+func (m *ResizeMode) Update(ctx *frame.Context) {
+	f := ctx.GetFocusedFrame()
+	if f == nil || f.IsOrphan() {
+		m.Exit(ctx)
+		return
+	}
+
+	label := resizeLabel(f)
+	ew, _ := xgraphics.Extents(prompt.DefaultInputTheme.Font, ctx.Config.TaskbarFontSize, label)
+	shape := frame.Rect{
+		W: ew + 2*ctx.Config.TaskbarXPad,
+		H: ctx.Config.TaskbarHeight,
+	}
+	shape.X = f.Shape.X + (f.Shape.W-shape.W)/2
+	shape.Y = f.Shape.Y + (f.Shape.H-shape.H)/2
+	m.OSD.MoveResize(shape.X, shape.Y, shape.W, shape.H)
+	m.OSD.Stack(xproto.StackModeAbove)
+	ext.Logerr(frame.DrawLabel(ctx, m.OSD, shape, ctx.Config.TaskbarBaseColor, label))
+}
+
+// Exit gives the keyboard back and removes the OSD.
+func (m *ResizeMode) Exit(ctx *frame.Context) {
+	if m.OSD == nil {
+		return
+	}
+	keybind.UngrabKeyboard(ctx.X)
+	keybind.Detach(ctx.X, m.OSD.Id)
+	m.OSD.Destroy()
+	m.OSD = nil
+	ctx.ExitKeyboardGrab = nil
+}
+
+// record saves the layout from before the first resize, so the whole resize can be undone in one go.
+func (m *ResizeMode) record(ctx *frame.Context) {
+	if !m.Resized {
+		ctx.RecordLayout()
+		m.Resized = true
+	}
+}
+
+// Enter grabs the keyboard so the arrow keys resize the focused frame, and shift with the arrow keys its container.
+func (m *ResizeMode) Enter(ctx *frame.Context) {
+	if m.OSD != nil {
+		return
+	}
+	f := ctx.GetFocusedFrame()
+	if f == nil || f.IsOrphan() {
+		return
+	}
+	m.Resized = false
+
+	decoration, err := frame.CreateDecoration(ctx, frame.Rect{X: 0, Y: 0, W: 1, H: 1}, ctx.Config.TaskbarBaseColor, 0)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	m.OSD = decoration.Window
+	if err := keybind.GrabKeyboard(ctx.X, m.OSD.Id); err != nil {
+		log.Println("could not grab keyboard for resizing:", err)
+		m.OSD.Destroy()
+		m.OSD = nil
+		return
+	}
+	m.OSD.Map()
+	ctx.ExitKeyboardGrab = func() { m.Exit(ctx) }
+
+	directions := map[string]frame.AnchorType{
+		"left":  frame.LEFT,
+		"right": frame.RIGHT,
+		"up":    frame.TOP,
+		"down":  frame.BOTTOM,
+	}
+	for k, d := range directions {
+		dir := d // capture separately so we can use in closure
+		ext.Logerr(keybind.KeyPressFun(func(X *xgbutil.XUtil, e xevent.KeyPressEvent) {
+			if ctx.Locked {
+				m.Exit(ctx)
+				return
+			}
+			if f := ctx.GetFocusedFrame(); f != nil && !f.IsOrphan() {
+				m.record(ctx)
+				f.GrowFrame(ctx, dir)
+			}
+			m.Update(ctx)
+		}).Connect(ctx.X, m.OSD.Id, k, false))
+
+		ext.Logerr(keybind.KeyPressFun(func(X *xgbutil.XUtil, e xevent.KeyPressEvent) {
+			if ctx.Locked {
+				m.Exit(ctx)
+				return
+			}
+			if f := ctx.GetFocusedFrame(); f != nil && !f.IsOrphan() {
+				m.record(ctx)
+				f.Container.GrowContainer(ctx, dir)
+			}
+			m.Update(ctx)
+		}).Connect(ctx.X, m.OSD.Id, "Shift-"+k, false))
+	}
+	for _, k := range []string{"Escape", "Return"} {
+		ext.Logerr(keybind.KeyPressFun(func(X *xgbutil.XUtil, e xevent.KeyPressEvent) {
+			m.Exit(ctx)
+		}).Connect(ctx.X, m.OSD.Id, k, false))
+	}
+	m.Update(ctx)
+}
+
+func RegisterResizeHooks(ctx *frame.Context) error {
+	mode := &ResizeMode{}
+	return keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		if ctx.Locked {
+			return
+		}
+
+		mode.Enter(ctx)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.ResizeMode.Data, true)
+}
@@ -0,0 +1,38 @@
+package root
+
+import (
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/levavakian/rowm/frame"
+)
+
+// MarkScratchpad moves the focused container into the scratchpad, or back out if it is already in it.
+func MarkScratchpad(ctx *frame.Context) {
+	focused := ctx.GetFocusedFrame()
+	if focused == nil || focused.IsOrphan() {
+		return
+	}
+	ctx.MarkScratchpad(focused.Container)
+}
+
+func RegisterScratchpadHooks(ctx *frame.Context) error {
+	err := keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		if ctx.Locked {
+			return
+		}
+
+		MarkScratchpad(ctx)
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.MarkScratchpad.Data, true)
+	if err != nil {
+		return err
+	}
+
+	return keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+		if ctx.Locked {
+			return
+		}
+
+		ctx.ToggleScratchpad()
+	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.ToggleScratchpad.Data, true)
+}
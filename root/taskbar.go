@@ -7,9 +7,12 @@ import (
 	"github.com/levavakian/rowm/frame"
 )
 
-type AnchorTo struct {
-	Anchor frame.AnchorType
-	Screen frame.Rect
+// ToggleTaskbar shows or hides the taskbar, keeping anchored containers anchored to the new work area.
+func ToggleTaskbar(ctx *frame.Context) {
+	frame.KeepAnchored(ctx, func() {
+		ctx.Taskbar.Hidden = !ctx.Taskbar.Hidden
+		ctx.Taskbar.UpdateMapping(ctx)
+	})
 }
 
 func RegisterTaskbarHooks(ctx *frame.Context) error {
@@ -20,30 +23,7 @@ func RegisterTaskbarHooks(ctx *frame.Context) error {
 			return
 		}
 
-		updates := make(map[*frame.Container]AnchorTo)
-		for c, _ := range ctx.Containers {
-			anchor := func() AnchorTo {
-				screen, _, index := ctx.GetScreenForShape(c.Shape)
-				if index != 0 {
-					return AnchorTo{frame.NONE, frame.Rect{}}
-				}
-				if opt := frame.AnchorMatch(ctx, screen, c.Shape); opt == frame.NONE {
-					return AnchorTo{frame.NONE, frame.Rect{}}
-				} else {
-					return AnchorTo{opt, screen}
-				}
-			}()
-			if anchor.Anchor != frame.NONE {
-				updates[c] = anchor
-			}
-		}
-
-		ctx.Taskbar.Hidden = !ctx.Taskbar.Hidden
-		ctx.Taskbar.UpdateMapping(ctx)
-
-		for c, anchor := range updates {
-			c.MoveResizeShape(ctx, frame.AnchorShape(ctx, anchor.Screen, anchor.Anchor))
-		}
+		ToggleTaskbar(ctx)
 	}).Connect(ctx.X, ctx.X.RootWin(), ctx.Config.ToggleTaskbar, true)
 	if err != nil {
 		return err
@@ -0,0 +1,65 @@
+package root
+
+import (
+	"github.com/BurntSushi/xgbutil"
+	"github.com/BurntSushi/xgbutil/keybind"
+	"github.com/BurntSushi/xgbutil/xevent"
+	"github.com/BurntSushi/xgbutil/xprop"
+	"github.com/levavakian/rowm/frame"
+	"log"
+)
+
+// MoveToWorkspace sends the focused container to a workspace, the screen stays on the current one.
+func MoveToWorkspace(ctx *frame.Context, workspace int) {
+	focused := ctx.GetFocusedFrame()
+	if focused == nil || focused.IsOrphan() {
+		return
+	}
+	focused.Container.MoveToWorkspace(ctx, workspace)
+}
+
+func RegisterWorkspaceHooks(ctx *frame.Context) error {
+	for i, key := range ctx.Config.WorkspaceKeys {
+		workspace := i // capture separately so we can use in closure
+		err := keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			if ctx.Locked {
+				return
+			}
+
+			ctx.SwitchWorkspace(workspace)
+		}).Connect(ctx.X, ctx.X.RootWin(), key, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i, key := range ctx.Config.MoveToWorkspaceKeys {
+		workspace := i
+		err := keybind.KeyReleaseFun(func(X *xgbutil.XUtil, e xevent.KeyReleaseEvent) {
+			if ctx.Locked {
+				return
+			}
+
+			MoveToWorkspace(ctx, workspace)
+		}).Connect(ctx.X, ctx.X.RootWin(), key, true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterWorkspaceRootHooks follows requests from pagers to switch workspaces.
+func RegisterWorkspaceRootHooks(ctx *frame.Context) {
+	xevent.ClientMessageFun(func(X *xgbutil.XUtil, ev xevent.ClientMessageEvent) {
+		name, err := xprop.AtomName(X, ev.Type)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if name != "_NET_CURRENT_DESKTOP" || ctx.Locked || len(ev.Data.Data32) == 0 {
+			return
+		}
+		ctx.SwitchWorkspace(int(ev.Data.Data32[0]))
+	}).Connect(ctx.X, ctx.X.RootWin())
+}
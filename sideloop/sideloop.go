@@ -41,6 +41,7 @@ func NewRepeater(f NoArgFunc, d time.Duration, inj *Injector) *Repeater {
 		Ticker:      ticker,
 	}
 	go func() {
+		defer func() { r.ConfirmChan <- true }()
 		for {
 			select {
 			case <-r.DoneChan:
@@ -59,7 +60,6 @@ func NewRepeater(f NoArgFunc, d time.Duration, inj *Injector) *Repeater {
 				}
 			}
 		}
-		r.ConfirmChan <- true
 	}()
 	return r
 }